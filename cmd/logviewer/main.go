@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"time"
 
 	"github.com/filipecaixeta/logviewer/internal/config"
 	"github.com/filipecaixeta/logviewer/internal/model"
+	"github.com/filipecaixeta/logviewer/internal/source"
 
 	"github.com/BurntSushi/toml"
 	tea "github.com/charmbracelet/bubbletea"
@@ -15,24 +17,33 @@ import (
 )
 
 var (
-	Version    = "dev"
-	Cfg        config.Config
-	cfgFile    string
-	namespaces []string
-	context    string
-	flagL      bool
-	flagD      bool
+	Version        = "dev"
+	Cfg            config.Config
+	cfgFile        string
+	namespaces     []string
+	context        string
+	kubeconfig     string
+	as             string
+	asGroups       []string
+	requestTimeout time.Duration
+	dockerHost     string
+	since          time.Duration
+	sinceTime      string
+	until          string
+	tail           int
+	flagL          bool
+	flagD          bool
 )
 
 // rootCmd represents the root command for the LogViewer TUI tool.
 var rootCmd = &cobra.Command{
 	Use:   "app",
 	Short: "LogViewer TUI",
-	Long: `This tool can be used to view logs from Kubernetes or Docker containers.. 
+	Long: `This tool can be used to view logs from Kubernetes or Docker containers and log files.
 
 Features:
 - Interactive List Navigation: Browse Kubernetes namespaces, workloads, pods, and containers using keyboard controls.
-- Real-time Logs Viewing: Stream logs from selected Docker or Kubernetes containers.
+- Real-time Logs Viewing: Stream logs from selected Docker or Kubernetes containers, or follow log files.
 - Log Filtering and Manipulation: Customize namespaces to display via a TOML configuration file.
 `,
 }
@@ -51,6 +62,35 @@ func commonRunE(commandName string) func(cmd *cobra.Command, args []string) erro
 		if cmd.Flags().Changed("context") {
 			Cfg.K8sContext = context
 		}
+		if cmd.Flags().Changed("kubeconfig") {
+			Cfg.Kubeconfig = kubeconfig
+		}
+		Cfg.K8sAs = as
+		Cfg.K8sAsGroups = asGroups
+		Cfg.K8sRequestTimeout = requestTimeout
+		if cmd.Flags().Changed("host") {
+			Cfg.DockerHost = dockerHost
+		}
+		now := time.Now()
+		Cfg.Since = since
+		Cfg.Tail = tail
+		if sinceTime != "" {
+			t, err := time.Parse(time.RFC3339, sinceTime)
+			if err != nil {
+				return fmt.Errorf("invalid --since-time: %w", err)
+			}
+			Cfg.SinceTime = t
+		}
+		if until != "" {
+			t, err := source.ParseTime(until, now)
+			if err != nil {
+				return fmt.Errorf("invalid --until: %w", err)
+			}
+			Cfg.Until = t
+		}
+		if since != 0 && sinceTime != "" {
+			return fmt.Errorf("--since and --since-time are mutually exclusive")
+		}
 		if cmd.Flags().Changed("light") {
 			Cfg.Color = "light"
 		}
@@ -112,6 +152,10 @@ func newK8sCmd() *cobra.Command {
 	}
 	c.PersistentFlags().StringSliceVarP(&namespaces, "namespaces", "n", []string{}, "namespaces to use")
 	c.PersistentFlags().StringVarP(&context, "context", "c", "", "k8s context to use")
+	c.PersistentFlags().StringVar(&kubeconfig, "kubeconfig", "", "path to the kubeconfig file, by default $KUBECONFIG or ~/.kube/config")
+	c.PersistentFlags().StringVar(&as, "as", "", "username to impersonate")
+	c.PersistentFlags().StringSliceVar(&asGroups, "as-group", []string{}, "groups to impersonate, can be repeated")
+	c.PersistentFlags().DurationVar(&requestTimeout, "request-timeout", 0, "time to wait for a single server request, 0 means no timeout")
 	return c
 }
 
@@ -124,6 +168,19 @@ func newStdinCmd() *cobra.Command {
 	}
 }
 
+func newFileCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:   "file <path>...",
+		Short: "File Command",
+		Long:  `Follow log files like tail -F, handling rotation and truncation.`,
+		Args:  cobra.MinimumNArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			Cfg.Files = args
+			return commonRunE("file")(cmd, args)
+		},
+	}
+}
+
 func newTestCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "test",
@@ -134,12 +191,17 @@ func newTestCmd() *cobra.Command {
 }
 
 func newDockerCmd() *cobra.Command {
-	return &cobra.Command{
+	c := &cobra.Command{
 		Use:   "docker",
 		Short: "Docker Command",
-		Long:  `Interact with Docker containers.`,
-		RunE:  commonRunE("docker"),
+		Long: `Interact with Docker containers.
+
+The daemon is the one of --host, otherwise $DOCKER_HOST, otherwise the local
+Docker socket, or the Podman socket when there is no Docker socket.`,
+		RunE: commonRunE("docker"),
 	}
+	c.PersistentFlags().StringVarP(&dockerHost, "host", "H", "", "daemon socket to connect to, like unix:///run/podman/podman.sock, tcp://host:2375 or ssh://user@host")
+	return c
 }
 
 func newVersionCmd() *cobra.Command {
@@ -157,6 +219,10 @@ func init() {
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file path")
 	rootCmd.PersistentFlags().BoolVarP(&flagL, "light", "l", false, "Use the light mode")
 	rootCmd.PersistentFlags().BoolVarP(&flagD, "dark", "d", true, "Use the dark mode (default)")
+	rootCmd.PersistentFlags().DurationVar(&since, "since", 0, "only show the logs newer than a duration like 15m or 2h")
+	rootCmd.PersistentFlags().StringVar(&sinceTime, "since-time", "", "only show the logs newer than a RFC3339 time like 2024-05-01T10:00:00Z")
+	rootCmd.PersistentFlags().StringVar(&until, "until", "", "only show the logs older than a RFC3339 time, or than a duration ago like 5m")
+	rootCmd.PersistentFlags().IntVar(&tail, "tail", 0, "number of lines loaded at start, -1 for all of them (default depends on the source)")
 	if err := viper.BindPFlags(rootCmd.PersistentFlags()); err != nil {
 		fmt.Println("Unable to bind flags:", err)
 	}
@@ -164,6 +230,7 @@ func init() {
 	rootCmd.AddCommand(newK8sCmd())
 	rootCmd.AddCommand(newStdinCmd())
 	rootCmd.AddCommand(newDockerCmd())
+	rootCmd.AddCommand(newFileCmd())
 	rootCmd.AddCommand(newTestCmd())
 	rootCmd.AddCommand(newVersionCmd())
 }
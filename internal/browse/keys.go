@@ -3,12 +3,15 @@ package browse
 import "github.com/charmbracelet/bubbles/key"
 
 type keyMap struct {
-	Up    key.Binding
-	Down  key.Binding
-	Left  key.Binding
-	Right key.Binding
-	Log   key.Binding
-	Quit  key.Binding
+	Up       key.Binding
+	Down     key.Binding
+	Left     key.Binding
+	Right    key.Binding
+	Log      key.Binding
+	Previous key.Binding
+	Context  key.Binding
+	All      key.Binding
+	Quit     key.Binding
 }
 
 var keys = keyMap{
@@ -32,6 +35,18 @@ var keys = keyMap{
 		key.WithKeys("enter"),
 		key.WithHelp("enter", "view logs"),
 	),
+	Previous: key.NewBinding(
+		key.WithKeys("p"),
+		key.WithHelp("p", "previous logs"),
+	),
+	Context: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "switch context"),
+	),
+	All: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "show all"),
+	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c", "q"),
 		key.WithHelp("ctrl+c/q", "quit"),
@@ -39,7 +54,38 @@ var keys = keyMap{
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Left, k.Right, k.Previous, k.Context, k.All, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding { return nil }
+
+type contextKeyMap struct {
+	Up     key.Binding
+	Down   key.Binding
+	Select key.Binding
+	Back   key.Binding
+	Quit   key.Binding
+}
+
+var contextKeys = contextKeyMap{
+	Up:   keys.Up,
+	Down: keys.Down,
+	Select: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "switch"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+func (k contextKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Down, k.Select, k.Back, k.Quit}
+}
+
+func (k contextKeyMap) FullHelp() [][]key.Binding { return nil }
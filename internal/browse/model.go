@@ -4,6 +4,7 @@ import (
 	"strings"
 
 	"github.com/filipecaixeta/logviewer/internal/common"
+	"github.com/filipecaixeta/logviewer/internal/config"
 	"github.com/filipecaixeta/logviewer/internal/source"
 	"github.com/filipecaixeta/logviewer/internal/state"
 
@@ -17,6 +18,7 @@ const footerHeight = 3
 
 var (
 	columnSpace = lipgloss.NewStyle().PaddingLeft(4).Render("")
+	errStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#f92672"))
 )
 
 type Model struct {
@@ -24,6 +26,29 @@ type Model struct {
 	ActiveColumn int
 	help         help.Model
 	common       *common.Common
+	// contexts is the context picker, nil when the source has no contexts
+	contexts       *source.List
+	pickingContext bool
+	// loadingContext is the context being loaded
+	loadingContext string
+	// err is the error of the source, like a context that
+	// couldn't be loaded, it is shown in the header
+	err error
+}
+
+// contextItem is an item of the context picker
+type contextItem string
+
+func (c contextItem) String() string {
+	return string(c)
+}
+
+func (c contextItem) Children() []source.ListItem {
+	return nil
+}
+
+func (c contextItem) FilterValue() string {
+	return string(c)
 }
 
 func New(c *common.Common) *Model {
@@ -32,6 +57,18 @@ func New(c *common.Common) *Model {
 		help:    help.New(),
 		common:  c,
 	}
+	if _, ok := c.Src.(source.ContextSource); ok {
+		m.contexts = source.NewList("Contexts", []source.ListItem{})
+		m.contexts.SetActive(true)
+	} else {
+		keys.Context.SetEnabled(false)
+	}
+	if _, ok := c.Src.(source.AllSource); !ok {
+		keys.All.SetEnabled(false)
+	}
+	if src, ok := c.Src.(source.PreviousSource); !ok || !src.HasPrevious() {
+		keys.Previous.SetEnabled(false)
+	}
 	m.UpdateChildren()
 	m.columns[m.ActiveColumn].SetActive(true)
 
@@ -47,10 +84,19 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
-		for _, col := range m.columns {
-			col.SetSize(msg.Width, msg.Height-footerHeight)
+		m.setSize()
+	case source.ErrorMsg:
+		m.setErr(msg.Err)
+	case source.ContextMsg:
+		m.loadingContext = ""
+		m.setErr(msg.Err)
+		if msg.Err == nil {
+			m.setActiveColumn(0)
 		}
 	case tea.KeyMsg:
+		if m.pickingContext {
+			return m, m.updateContexts(msg)
+		}
 		switch {
 		case key.Matches(msg, keys.Up), key.Matches(msg, keys.Down):
 			m.columns[m.ActiveColumn], cmd = m.columns[m.ActiveColumn].Update(msg)
@@ -70,7 +116,21 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.columns[m.ActiveColumn], cmd = m.columns[m.ActiveColumn].Update(msg)
 			m.UpdateChildren()
 		case key.Matches(msg, keys.Log):
+			m.common.LogOptions.Previous = false
 			m.common.SetState(state.StateLogsLoading)
+		case key.Matches(msg, keys.Previous):
+			m.common.LogOptions.Previous = true
+			m.common.SetState(state.StateLogsLoading)
+		case key.Matches(msg, keys.Context):
+			cmd = m.openContexts()
+		case key.Matches(msg, keys.All):
+			src := m.common.Src.(source.AllSource)
+			src.SetShowAll(!src.ShowAll())
+			if src.ShowAll() {
+				keys.All.SetHelp("a", "hide stopped")
+			} else {
+				keys.All.SetHelp("a", "show all")
+			}
 		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
 		}
@@ -80,16 +140,27 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 }
 
 func (m *Model) View() string {
-	h := m.help.View(keys)
+	var h, body string
+	if m.pickingContext {
+		h = m.help.View(contextKeys)
+		body = m.contexts.View()
+	} else {
+		h = m.help.View(keys)
+		body = m.columnsView()
+	}
 	helpHeight := strings.Count(h, "\n")
 
+	body = m.header() + body
+	r := m.common.Height - 2 - helpHeight - strings.Count(body, "\n")
+	if r < 0 {
+		r = 0
+	}
+	return body + strings.Repeat("\n", r) + h
+}
+
+func (m *Model) columnsView() string {
 	if len(m.columns) == 1 {
-		listView := m.columns[0].View()
-		r := m.common.Height - 2 - helpHeight - strings.Count(listView, "\n")
-		if r < 0 {
-			r = 0
-		}
-		return listView + strings.Repeat("\n", r) + h
+		return m.columns[0].View()
 	}
 
 	col := m.ActiveColumn
@@ -99,14 +170,100 @@ func (m *Model) View() string {
 	list1View := m.columns[col].View()
 	list2View := m.columns[col+1].View()
 
-	columnsView := lipgloss.JoinHorizontal(lipgloss.Top, list1View, columnSpace, list2View)
+	return lipgloss.JoinHorizontal(lipgloss.Top, list1View, columnSpace, list2View)
+}
 
-	r := m.common.Height - 2 - helpHeight - strings.Count(columnsView, "\n")
-	if r < 0 {
-		r = 0
+// header shows the active context of the sources that have contexts
+// and the error of the source
+func (m *Model) header() string {
+	if m.headerHeight() == 0 {
+		return ""
+	}
+	var header string
+	if m.contexts != nil {
+		name := m.common.Src.(source.ContextSource).Context()
+		if name == "" {
+			name = "in-cluster"
+		}
+		header = config.ListStyle.Render("context ") + config.ListActiveStyle.Render(name) + config.ListStyle.Render("  ")
+	}
+	if m.loadingContext != "" {
+		header += config.ListStyle.Render("loading " + m.loadingContext + " ...")
+	} else if m.err != nil {
+		header += errStyle.Render(strings.ReplaceAll(m.err.Error(), "\n", " "))
+	}
+	// the header is kept on a single line
+	return lipgloss.NewStyle().MaxWidth(m.common.Width).Render(header) + "\n"
+}
+
+func (m *Model) headerHeight() int {
+	if m.contexts == nil && m.err == nil {
+		return 0
+	}
+	return 1
+}
+
+// setSize sets the size of the lists to fill the screen below the header
+func (m *Model) setSize() {
+	for _, col := range m.columns {
+		col.SetSize(m.common.Width, m.common.Height-footerHeight-m.headerHeight())
+	}
+	if m.contexts != nil {
+		m.contexts.SetSize(m.common.Width, m.common.Height-footerHeight-m.headerHeight())
+	}
+}
+
+// setErr shows the error in the header, the lists are resized
+// since the header may appear or go away
+func (m *Model) setErr(err error) {
+	m.err = err
+	m.setSize()
+}
+
+// openContexts shows the context picker with the active context selected
+func (m *Model) openContexts() tea.Cmd {
+	src := m.common.Src.(source.ContextSource)
+	names, err := src.Contexts()
+	if err != nil {
+		// shown in the header like the other errors of the source
+		return func() tea.Msg { return source.ErrorMsg{Err: err} }
+	}
+
+	items := make([]source.ListItem, len(names))
+	selected := 0
+	for i, name := range names {
+		items[i] = contextItem(name)
+		if name == src.Context() {
+			selected = i
+		}
 	}
+	m.contexts.SetItems(items)
+	m.contexts.ResetFilter()
+	m.contexts.Select(selected)
+	m.pickingContext = true
+	return nil
+}
 
-	return columnsView + strings.Repeat("\n", r) + h
+// updateContexts handles the keys of the context picker. Choosing another
+// context loads the namespaces of the new cluster again.
+func (m *Model) updateContexts(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, contextKeys.Up), key.Matches(msg, contextKeys.Down):
+		m.contexts, _ = m.contexts.Update(msg)
+	case key.Matches(msg, contextKeys.Back):
+		m.pickingContext = false
+	case key.Matches(msg, contextKeys.Select):
+		m.pickingContext = false
+		item := m.contexts.SelectedItem()
+		src := m.common.Src.(source.ContextSource)
+		if item == nil || item.String() == src.Context() || m.loadingContext != "" {
+			return nil
+		}
+		m.loadingContext = item.String()
+		m.setErr(nil)
+		return src.SetContext(item.String())
+	}
+	return nil
 }
 
 func (m *Model) UpdateChildren() {
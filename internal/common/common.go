@@ -20,6 +20,8 @@ type Common struct {
 	Models    []tea.Model
 	Cfg       *config.Config
 	Src       source.Source
+	// LogOptions are the options of the next log stream
+	LogOptions source.LogOptions
 }
 
 func New(cfg *config.Config) *Common {
@@ -31,6 +33,12 @@ func New(cfg *config.Config) *Common {
 		State:     state.StateLoading,
 		StateChan: make(chan state.State),
 		Cfg:       cfg,
+		LogOptions: source.LogOptions{
+			Since:     cfg.Since,
+			SinceTime: cfg.SinceTime,
+			Until:     cfg.Until,
+			Tail:      cfg.Tail,
+		},
 	}
 }
 
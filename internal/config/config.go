@@ -2,29 +2,67 @@ package config
 
 import (
 	"os"
+	"time"
 
 	"github.com/charmbracelet/lipgloss"
 	"github.com/pelletier/go-toml/v2"
 )
 
 type Config struct {
-	Color      string   `json:"color,omitempty" toml:"color,omitempty"`
-	Command    string   `json:"command,omitempty" toml:"-"`
-	Filename   string   `json:"filename,omitempty" toml:"-"`
-	Namespaces []string `json:"namespaces,omitempty" toml:"namespaces,omitempty"`
-	K8sContext string   `json:"k8sContext,omitempty" toml:"k8sContext,omitempty"`
-	Views      []View   `json:"views,omitempty" toml:"views,omitempty"`
+	Color             string        `json:"color,omitempty" toml:"color,omitempty"`
+	Command           string        `json:"command,omitempty" toml:"-"`
+	Filename          string        `json:"filename,omitempty" toml:"-"`
+	Files             []string      `json:"files,omitempty" toml:"-"`
+	Namespaces        []string      `json:"namespaces,omitempty" toml:"namespaces,omitempty"`
+	K8sContext        string        `json:"k8sContext,omitempty" toml:"k8sContext,omitempty"`
+	Kubeconfig        string        `json:"kubeconfig,omitempty" toml:"kubeconfig,omitempty"`
+	K8sAs             string        `json:"k8sAs,omitempty" toml:"-"`
+	K8sAsGroups       []string      `json:"k8sAsGroups,omitempty" toml:"-"`
+	K8sRequestTimeout time.Duration `json:"k8sRequestTimeout,omitempty" toml:"-"`
+	DockerHost        string        `json:"dockerHost,omitempty" toml:"dockerHost,omitempty"`
+	Since             time.Duration `json:"since,omitempty" toml:"-"`
+	SinceTime         time.Time     `json:"sinceTime,omitempty" toml:"-"`
+	Until             time.Time     `json:"until,omitempty" toml:"-"`
+	Tail              int           `json:"tail,omitempty" toml:"-"`
+	Views             []View        `json:"views,omitempty" toml:"views,omitempty"`
 }
 
 type View struct {
 	Name           string      `json:"name" toml:"name"`
 	Selector       string      `json:"selector,omitempty" toml:"selector,omitempty"`
+	Parser         string      `json:"parser,omitempty" toml:"parser,omitempty"`
+	Patterns       []Pattern   `json:"patterns,omitempty" toml:"patterns,omitempty"`
+	DecodeNested   bool        `json:"decodeNested,omitempty" toml:"decodeNested,omitempty"`
+	Multiline      *Multiline  `json:"multiline,omitempty" toml:"multiline,omitempty"`
 	ReturnedFields []string    `json:"returnedFields,omitempty" toml:"returnedFields,omitempty"`
+	Display        string      `json:"display,omitempty" toml:"display,omitempty"`
 	Filter         string      `json:"filter,omitempty" toml:"filter,omitempty"`
 	FilterDefault  bool        `json:"filterDefault,omitempty" toml:"filterDefault,omitempty"`
 	Transforms     []Transform `json:"transforms,omitempty" toml:"transforms,omitempty"`
 }
 
+// Pattern is a regular expression whose named groups are the fields of
+// the lines it matches, Preset is the name of a built-in pattern
+type Pattern struct {
+	Preset     string            `json:"preset,omitempty" toml:"preset,omitempty"`
+	Regex      string            `json:"regex,omitempty" toml:"regex,omitempty"`
+	Types      map[string]string `json:"types,omitempty" toml:"types,omitempty"`
+	TimeLayout string            `json:"timeLayout,omitempty" toml:"timeLayout,omitempty"`
+}
+
+// Multiline merges the lines of the entries spanning many lines, like
+// stack traces. Presets are the names of built-in continuation patterns
+// and Timeout, a duration like "500ms", is how long an entry waits for
+// more lines. MaxLines is the most lines merged in an entry
+type Multiline struct {
+	Presets      []string `json:"presets,omitempty" toml:"presets,omitempty"`
+	Start        []string `json:"start,omitempty" toml:"start,omitempty"`
+	Continuation []string `json:"continuation,omitempty" toml:"continuation,omitempty"`
+	Indent       bool     `json:"indent,omitempty" toml:"indent,omitempty"`
+	Timeout      string   `json:"timeout,omitempty" toml:"timeout,omitempty"`
+	MaxLines     int      `json:"maxLines,omitempty" toml:"maxLines,omitempty"`
+}
+
 type Transform struct {
 	Field      string `json:"field" toml:"field"`
 	Expression string `json:"expression" toml:"expression"`
@@ -0,0 +1,364 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// The views are saved by editing the text of the config file, only the
+// keys and tables that changed are rewritten so the comments and the
+// formatting of the rest of the file are kept.
+
+// AddView appends a view to the config and to the config file
+func (c *Config) AddView(v View) error {
+	src, err := c.readFile()
+	if err != nil {
+		return err
+	}
+	block, err := encodeView(v)
+	if err != nil {
+		return err
+	}
+	if len(src) > 0 && !bytes.HasSuffix(src, []byte("\n")) {
+		src = append(src, '\n')
+	}
+	if len(src) > 0 {
+		src = append(src, '\n')
+	}
+	src = append(src, block...)
+	if err := c.writeFile(src); err != nil {
+		return err
+	}
+	c.Views = append(c.Views, v)
+	return nil
+}
+
+// UpdateView replaces the view at index i, in the config file only the
+// keys and tables of the view that changed are rewritten
+func (c *Config) UpdateView(i int, v View) error {
+	src, err := c.readFile()
+	if err != nil {
+		return err
+	}
+	old := c.Views[i]
+	if _, err := findView(src, i, old.Name); err != nil {
+		return err
+	}
+
+	ov, nv := reflect.ValueOf(old), reflect.ValueOf(v)
+	for f := 0; f < ov.NumField(); f++ {
+		if reflect.DeepEqual(ov.Field(f).Interface(), nv.Field(f).Interface()) {
+			continue
+		}
+		key := tomlKey(ov.Type().Field(f))
+		if key == "" {
+			continue
+		}
+		if isTable(nv.Field(f)) {
+			src, err = setTables(src, i, key, ov.Field(f), nv.Field(f))
+		} else {
+			// the file is scanned again after every edit, the offsets change
+			b, _ := findView(src, i, "")
+			src, err = setKey(src, b.keys, b.header.end, key, nv.Field(f))
+		}
+		if err != nil {
+			return fmt.Errorf("saving %s of view %s: %w", key, v.Name, err)
+		}
+	}
+	if err := c.writeFile(src); err != nil {
+		return err
+	}
+	c.Views[i] = v
+	return nil
+}
+
+// DeleteView removes the view at index i from the config and the config file
+func (c *Config) DeleteView(i int) error {
+	src, err := c.readFile()
+	if err != nil {
+		return err
+	}
+	b, err := findView(src, i, c.Views[i].Name)
+	if err != nil {
+		return err
+	}
+	end := b.end
+	// drop the blank line that separated the view from the next one
+	if bytes.HasSuffix(src[:b.start], []byte("\n\n")) && bytes.HasPrefix(src[end:], []byte("\n")) {
+		end++
+	}
+	src = append(src[:b.start:b.start], src[end:]...)
+	if err := c.writeFile(src); err != nil {
+		return err
+	}
+	c.Views = append(c.Views[:i], c.Views[i+1:]...)
+	return nil
+}
+
+func (c *Config) readFile() ([]byte, error) {
+	if c.Filename == "" {
+		return nil, fmt.Errorf("there is no config file")
+	}
+	src, err := os.ReadFile(c.Filename)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return src, err
+}
+
+// writeFile replaces the config file, the new file is written
+// next to it first so it is never left half written
+func (c *Config) writeFile(src []byte) error {
+	mode := os.FileMode(0o644)
+	if info, err := os.Stat(c.Filename); err == nil {
+		mode = info.Mode().Perm()
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(c.Filename), ".config-*.toml")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(src); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), mode); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), c.Filename)
+}
+
+// tomlKey returns the key of a field, empty if it isn't saved
+func tomlKey(f reflect.StructField) string {
+	key, _, _ := strings.Cut(f.Tag.Get("toml"), ",")
+	if key == "-" {
+		return ""
+	}
+	return key
+}
+
+// isTable reports whether a field is written as tables, like
+// [[views.transforms]], instead of a key of the view
+func isTable(v reflect.Value) bool {
+	t := v.Type()
+	if t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t.Kind() == reflect.Struct
+}
+
+// encodeView returns the [[views]] table of a view
+func encodeView(v View) ([]byte, error) {
+	var b bytes.Buffer
+	if err := toml.NewEncoder(&b).Encode(struct {
+		Views []View `toml:"views"`
+	}{[]View{v}}); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// encodeValue returns the value of a key as it is written in the file
+func encodeValue(v reflect.Value) (string, error) {
+	var b bytes.Buffer
+	var err error
+	if s, ok := v.Interface().(string); ok && strings.Contains(s, "\n") {
+		err = toml.NewEncoder(&b).Encode(struct {
+			V string `toml:"v,multiline"`
+		}{s})
+	} else {
+		enc := toml.NewEncoder(&b)
+		// the maps, like the types of a pattern, are written as inline tables
+		enc.SetTablesInline(true)
+		err = enc.Encode(map[string]interface{}{"v": v.Interface()})
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(strings.TrimPrefix(b.String(), "v = "), "\n"), nil
+}
+
+// setKey sets the value of a key of the view or of one of its tables, the
+// key is removed when the value is empty. New keys are written after the
+// last of the keys, or at the position at when there are none
+func setKey(src []byte, keys []tomlStmt, at int, key string, value reflect.Value) ([]byte, error) {
+	var stmt *tomlStmt
+	for i := range keys {
+		if keys[i].name == key {
+			stmt = &keys[i]
+		}
+	}
+
+	if value.IsZero() || (value.Kind() == reflect.Slice && value.Len() == 0) {
+		if stmt == nil {
+			return src, nil
+		}
+		return splice(src, stmt.start, stmt.end, ""), nil
+	}
+
+	encoded, err := encodeValue(value)
+	if err != nil {
+		return nil, err
+	}
+	if stmt != nil {
+		return splice(src, stmt.valueStart, stmt.valueEnd, encoded), nil
+	}
+	if len(keys) > 0 {
+		at = keys[len(keys)-1].end
+	}
+	return splice(src, at, at, key+" = "+encoded+"\n"), nil
+}
+
+// setTables sets the tables of a field of the i-th view, like its
+// [[views.transforms]]. The tables that didn't change are kept, the
+// changed ones are edited key by key so their comments are kept too
+func setTables(src []byte, i int, key string, old, value reflect.Value) ([]byte, error) {
+	olds, news := tableItems(old), tableItems(value)
+	// the file is scanned again after every edit, the offsets change
+	tables := func(src []byte) (viewBlock, []viewTable) {
+		b, _ := findView(src, i, "")
+		var tables []viewTable
+		for _, t := range b.tables {
+			if t.field == key {
+				tables = append(tables, t)
+			}
+		}
+		return b, tables
+	}
+	if _, t := tables(src); len(t) != len(olds) {
+		return nil, fmt.Errorf("the tables of %s don't match the config file, it was changed", key)
+	}
+
+	// the new tables go after the last one, or at the end of the view
+	if len(news) > len(olds) {
+		encoded, err := encodeTables(key, news[len(olds):])
+		if err != nil {
+			return nil, err
+		}
+		b, t := tables(src)
+		at := b.end
+		if len(t) > 0 {
+			at = t[len(t)-1].end
+		}
+		if at > 0 && src[at-1] != '\n' {
+			encoded = "\n" + encoded
+		}
+		src = splice(src, at, at, encoded)
+	}
+
+	// the tables are removed and edited from the last one, so the
+	// tables before are where they were scanned
+	for j := len(olds) - 1; j >= len(news); j-- {
+		_, t := tables(src)
+		start := t[j].start
+		// and the blank line before them
+		if bytes.HasSuffix(src[:start], []byte("\n\n")) {
+			start--
+		}
+		src = splice(src, start, t[j].end, "")
+	}
+	for j := min(len(olds), len(news)) - 1; j >= 0; j-- {
+		if reflect.DeepEqual(olds[j].Interface(), news[j].Interface()) {
+			continue
+		}
+		var err error
+		src, err = setTable(src, key, func(src []byte) viewTable { _, t := tables(src); return t[j] }, olds[j], news[j])
+		if err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
+// setTable edits the keys of a table that changed, the table is written
+// again when it has tables of its own
+func setTable(src []byte, key string, table func(src []byte) viewTable, old, value reflect.Value) ([]byte, error) {
+	if t := table(src); t.sub {
+		encoded, err := encodeTables(key, []reflect.Value{value})
+		if err != nil {
+			return nil, err
+		}
+		return splice(src, t.start, t.end, strings.TrimPrefix(encoded, "\n")), nil
+	}
+	for f := 0; f < old.NumField(); f++ {
+		if reflect.DeepEqual(old.Field(f).Interface(), value.Field(f).Interface()) {
+			continue
+		}
+		name := tomlKey(old.Type().Field(f))
+		if name == "" {
+			continue
+		}
+		t := table(src)
+		var err error
+		src, err = setKey(src, t.keys, t.header.end, name, value.Field(f))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return src, nil
+}
+
+// tableItems returns the structs written as tables of a field,
+// the items of a slice or the struct of a pointer
+func tableItems(v reflect.Value) []reflect.Value {
+	var items []reflect.Value
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			items = append(items, v.Elem())
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			items = append(items, v.Index(i))
+		}
+	}
+	return items
+}
+
+// encodeTables returns the tables of the items of a field, as they are
+// written in a view, with a blank line before them
+func encodeTables(key string, items []reflect.Value) (string, error) {
+	// encode a view with only these items to get their tables
+	var only View
+	field := reflect.ValueOf(&only).Elem().FieldByName(fieldName(only, key))
+	if field.Kind() == reflect.Pointer {
+		field.Set(reflect.New(field.Type().Elem()))
+		field.Elem().Set(items[0])
+	} else {
+		field.Set(reflect.Append(reflect.MakeSlice(field.Type(), 0, len(items)), items...))
+	}
+	encoded, err := encodeView(only)
+	if err != nil {
+		return "", err
+	}
+	if i := bytes.Index(encoded, []byte("\n[")); i >= 0 {
+		return string(encoded[i:]), nil
+	}
+	return "", nil
+}
+
+func fieldName(v View, key string) string {
+	t := reflect.TypeOf(v)
+	for i := 0; i < t.NumField(); i++ {
+		if tomlKey(t.Field(i)) == key {
+			return t.Field(i).Name
+		}
+	}
+	return ""
+}
+
+func splice(src []byte, start, end int, s string) []byte {
+	out := make([]byte, 0, len(src)-(end-start)+len(s))
+	out = append(out, src[:start]...)
+	out = append(out, s...)
+	return append(out, src[end:]...)
+}
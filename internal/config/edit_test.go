@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+// loadTemplate copies the config template to a temporary file and loads it
+func loadTemplate(t *testing.T) *Config {
+	t.Helper()
+	src, err := os.ReadFile("../../config.template.toml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Config{Filename: filepath.Join(t.TempDir(), "config.toml")}
+	if err := os.WriteFile(c.Filename, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := toml.Unmarshal(src, c); err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Views) == 0 {
+		t.Fatal("the template has no views")
+	}
+	return c
+}
+
+// checkFile decodes the config file and compares its views with the config
+func checkFile(t *testing.T, c *Config, step string) string {
+	t.Helper()
+	src, err := os.ReadFile(c.Filename)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var saved Config
+	if err := toml.Unmarshal(src, &saved); err != nil {
+		t.Fatalf("%s: decoding the config file: %v\n%s", step, err, src)
+	}
+	if !reflect.DeepEqual(saved.Views, c.Views) {
+		t.Fatalf("%s: the views of the file are\n%#v\nwant\n%#v\n%s", step, saved.Views, c.Views, src)
+	}
+	return string(src)
+}
+
+func TestViewsRoundTrip(t *testing.T) {
+	c := loadTemplate(t)
+	comments := []string{
+		"# Specify the name of the view.",
+		"# Specify the field that will be created or modified.",
+		"# Expression to transform the 'ts' field from Unix time to a human-readable date format.",
+		"# The fields are strings unless they are converted to \"int\", \"float\" or \"time\".",
+		"# The first lines of the entries, the lines that don't match continue the previous entry.",
+	}
+
+	v := c.Views[0]
+	v.Filter = `json.level == "error"`
+	v.ReturnedFields = []string{"ts", "level", "msg"}
+	v.Transforms = []Transform{
+		{Field: "ts", Expression: "toLocalDateStr(json.time)"},
+		{Field: "msg", Expression: "upper(json.msg)"},
+	}
+	v.Patterns = append([]Pattern(nil), v.Patterns...)
+	v.Patterns[1].Types = map[string]string{"time": "time", "thread": "int"}
+	v.Patterns[1].TimeLayout = "2006-01-02 15:04:05"
+	v.Multiline = &Multiline{Presets: []string{"go"}, Indent: true}
+	if err := c.UpdateView(0, v); err != nil {
+		t.Fatal(err)
+	}
+	src := checkFile(t, c, "update")
+	for _, comment := range comments {
+		if !strings.Contains(src, comment) {
+			t.Errorf("update: the comment %q was dropped\n%s", comment, src)
+		}
+	}
+
+	v.Transforms = v.Transforms[:1]
+	v.Patterns = v.Patterns[1:]
+	v.Multiline = nil
+	if err := c.UpdateView(0, v); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, c, "remove tables")
+
+	added := View{
+		Name:       "errors",
+		Filter:     `json.level == "error"`,
+		Patterns:   []Pattern{{Regex: `^(?P<msg>.*)$`, Types: map[string]string{"msg": "string"}}},
+		Transforms: []Transform{{Field: "level", Expression: "lower(json.level)"}},
+	}
+	if err := c.AddView(added); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, c, "add")
+
+	added.Transforms = append(added.Transforms, Transform{Field: "msg", Expression: "json.message"})
+	added.Patterns = []Pattern{{Regex: `^(?P<msg>.*)$`, Types: map[string]string{"msg": "string", "n": "int"}}}
+	if err := c.UpdateView(1, added); err != nil {
+		t.Fatal(err)
+	}
+	checkFile(t, c, "update added")
+
+	if err := c.DeleteView(0); err != nil {
+		t.Fatal(err)
+	}
+	src = checkFile(t, c, "delete")
+	if strings.Contains(src, "even info logs") {
+		t.Errorf("delete: the view is still in the file\n%s", src)
+	}
+}
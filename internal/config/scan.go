@@ -0,0 +1,258 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+)
+
+type stmtKind int
+
+const (
+	stmtOther stmtKind = iota
+	stmtTable
+	stmtArrayTable
+	stmtKeyValue
+)
+
+// tomlStmt is a line of a TOML file, or a key whose value spans many lines
+type tomlStmt struct {
+	kind stmtKind
+	// name is the name of the table or the key
+	name       string
+	start, end int
+	// valueStart and valueEnd are the position of the value of a key
+	valueStart, valueEnd int
+}
+
+// viewBlock is the text of a [[views]] table and its sub-tables, from the
+// header to the end of its last key. The comments after it are left out,
+// they are usually about what comes next
+type viewBlock struct {
+	start, end int
+	header     tomlStmt
+	keys       []tomlStmt
+	tables     []viewTable
+}
+
+// viewTable is a sub-table of a view, like [[views.transforms]], and its
+// keys. sub is set when it has tables of its own, like [views.patterns.types],
+// the keys after them aren't its own
+type viewTable struct {
+	field      string
+	start, end int
+	header     tomlStmt
+	keys       []tomlStmt
+	sub        bool
+}
+
+// findView returns the block of the i-th view of the file, its name must
+// match so a file changed after it was loaded isn't overwritten. The name
+// isn't checked when it is empty
+func findView(src []byte, i int, name string) (viewBlock, error) {
+	blocks := scanViews(scanStmts(string(src)))
+	if i >= len(blocks) {
+		return viewBlock{}, fmt.Errorf("view %s is not in the config file, it was changed", name)
+	}
+	b := blocks[i]
+	for _, k := range b.keys {
+		if k.name != "name" || name == "" {
+			continue
+		}
+		var v struct {
+			V string `toml:"v"`
+		}
+		value := append([]byte("v = "), src[k.valueStart:k.valueEnd]...)
+		if err := toml.Unmarshal(value, &v); err != nil || v.V != name {
+			return viewBlock{}, fmt.Errorf("view %s is not in the config file, it was changed", name)
+		}
+	}
+	return b, nil
+}
+
+func scanViews(stmts []tomlStmt) []viewBlock {
+	var blocks []viewBlock
+	var b *viewBlock
+	var table *viewTable
+	for _, s := range stmts {
+		switch s.kind {
+		case stmtTable, stmtArrayTable:
+			if s.kind == stmtArrayTable && s.name == "views" {
+				blocks = append(blocks, viewBlock{start: s.start, end: s.end, header: s})
+				b = &blocks[len(blocks)-1]
+				table = nil
+				continue
+			}
+			field, ok := strings.CutPrefix(s.name, "views.")
+			if b == nil || !ok {
+				b = nil
+				continue
+			}
+			field, _, _ = strings.Cut(field, ".")
+			if table == nil || table.field != field || (s.kind == stmtArrayTable && s.name == "views."+field) {
+				b.tables = append(b.tables, viewTable{field: field, start: s.start, header: s})
+				table = &b.tables[len(b.tables)-1]
+			} else {
+				table.sub = true
+			}
+			table.end = s.end
+			b.end = s.end
+		case stmtKeyValue:
+			if b == nil {
+				continue
+			}
+			if table == nil {
+				b.keys = append(b.keys, s)
+			} else {
+				if !table.sub {
+					table.keys = append(table.keys, s)
+				}
+				table.end = s.end
+			}
+			b.end = s.end
+		}
+	}
+	return blocks
+}
+
+// scanStmts splits a TOML file in statements, it only understands as much
+// of TOML as needed to find where the tables and the values are
+func scanStmts(src string) []tomlStmt {
+	var stmts []tomlStmt
+	for pos := 0; pos < len(src); {
+		s := tomlStmt{start: pos}
+		i := skipSpaces(src, pos)
+		switch {
+		case i == len(src) || src[i] == '\n' || src[i] == '\r' || src[i] == '#':
+			s.kind = stmtOther
+		case strings.HasPrefix(src[i:], "[["):
+			s.kind = stmtArrayTable
+			end := strings.Index(src[i:], "]]")
+			if end < 0 {
+				end = len(src) - i
+			}
+			s.name = normalizeKey(src[i+2 : i+end])
+		case src[i] == '[':
+			s.kind = stmtTable
+			end := strings.IndexByte(src[i:], ']')
+			if end < 0 {
+				end = len(src) - i
+			}
+			s.name = normalizeKey(src[i+1 : i+end])
+		default:
+			eq := keyEnd(src, i)
+			s.kind = stmtKeyValue
+			s.name = normalizeKey(src[i:eq])
+			s.valueStart = skipSpaces(src, min(eq+1, len(src)))
+			s.valueEnd = valueEnd(src, s.valueStart)
+			i = s.valueEnd
+		}
+		// the rest of the line, with its comment
+		if nl := strings.IndexByte(src[i:], '\n'); nl >= 0 {
+			pos = i + nl + 1
+		} else {
+			pos = len(src)
+		}
+		s.end = pos
+		stmts = append(stmts, s)
+	}
+	return stmts
+}
+
+func skipSpaces(src string, i int) int {
+	for i < len(src) && (src[i] == ' ' || src[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+// keyEnd returns the position of the = after a key, which may be quoted
+func keyEnd(src string, i int) int {
+	for i < len(src) && src[i] != '=' && src[i] != '\n' {
+		if src[i] == '"' || src[i] == '\'' {
+			i = valueEnd(src, i)
+			continue
+		}
+		i++
+	}
+	return i
+}
+
+// valueEnd returns the position after the value starting at i
+func valueEnd(src string, i int) int {
+	switch {
+	case strings.HasPrefix(src[i:], `"""`), strings.HasPrefix(src[i:], `'''`):
+		quote := src[i : i+3]
+		j := i + 3
+		for j < len(src) {
+			if quote[0] == '"' && src[j] == '\\' {
+				j += 2
+				continue
+			}
+			if strings.HasPrefix(src[j:], quote) {
+				j += 3
+				// up to two quotes may be part of the string
+				for n := 0; n < 2 && j < len(src) && src[j] == quote[0]; n++ {
+					j++
+				}
+				return j
+			}
+			j++
+		}
+		return len(src)
+	case i < len(src) && (src[i] == '"' || src[i] == '\''):
+		j := i + 1
+		for j < len(src) && src[j] != src[i] && src[j] != '\n' {
+			if src[i] == '"' && src[j] == '\\' {
+				j++
+			}
+			j++
+		}
+		return min(j+1, len(src))
+	case i < len(src) && (src[i] == '[' || src[i] == '{'):
+		depth := 0
+		for j := i; j < len(src); {
+			switch src[j] {
+			case '"', '\'':
+				j = valueEnd(src, j)
+				continue
+			case '#':
+				for j < len(src) && src[j] != '\n' {
+					j++
+				}
+				continue
+			case '[', '{':
+				depth++
+			case ']', '}':
+				depth--
+				if depth == 0 {
+					return j + 1
+				}
+			}
+			j++
+		}
+		return len(src)
+	}
+	j := i
+	for j < len(src) && src[j] != '\n' && src[j] != '#' {
+		j++
+	}
+	return len(strings.TrimRight(src[:j], " \t\r"))
+}
+
+// normalizeKey removes the spaces and the quotes of a dotted key
+func normalizeKey(key string) string {
+	parts := strings.Split(key, ".")
+	for i, p := range parts {
+		parts[i] = unquote(strings.TrimSpace(p))
+	}
+	return strings.Join(parts, ".")
+}
+
+func unquote(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '\'') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return s
+}
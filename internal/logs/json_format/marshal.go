@@ -17,7 +17,7 @@ type Style struct {
 	Generic string `json:"generic"`
 }
 
-const resetStyle = "\033[0m"
+const ResetStyle = "\033[0m"
 
 var DarkStyle = Style{
 	Null:    "\033[38;5;47m",
@@ -44,19 +44,19 @@ func PrettyPrintJSON(obj map[string]interface{}, indent int, style *Style) strin
 func formatJSONValue(value interface{}, indent int, currentIndent int, style *Style) string {
 	switch v := value.(type) {
 	case string:
-		return fmt.Sprintf("%s\"%s\"%s", style.String, v, resetStyle)
+		return fmt.Sprintf("%s\"%s\"%s", style.String, v, ResetStyle)
 	case bool:
-		return fmt.Sprintf("%s%t%s", style.Boolean, v, resetStyle)
+		return fmt.Sprintf("%s%t%s", style.Boolean, v, ResetStyle)
 	case nil:
-		return fmt.Sprintf("%snull%s", style.Null, resetStyle)
+		return fmt.Sprintf("%snull%s", style.Null, ResetStyle)
 	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
-		return fmt.Sprintf("%s%d%s", style.Numeric, v, resetStyle)
+		return fmt.Sprintf("%s%d%s", style.Numeric, v, ResetStyle)
 	case float64:
-		return fmt.Sprintf("%s%s%s", style.Numeric, strconv.FormatFloat(v, 'f', -1, 64), resetStyle)
+		return fmt.Sprintf("%s%s%s", style.Numeric, strconv.FormatFloat(v, 'f', -1, 64), ResetStyle)
 	case float32:
-		return fmt.Sprintf("%s%s%s", style.Numeric, strconv.FormatFloat(float64(v), 'f', -1, 64), resetStyle)
+		return fmt.Sprintf("%s%s%s", style.Numeric, strconv.FormatFloat(float64(v), 'f', -1, 64), ResetStyle)
 	case time.Time:
-		return fmt.Sprintf("%s\"%s\"%s", style.String, v.Format(time.RFC3339), resetStyle)
+		return fmt.Sprintf("%s\"%s\"%s", style.String, v.Format(time.RFC3339), ResetStyle)
 	case map[string]interface{}:
 		return formatJSONObject(v, indent, currentIndent, style)
 	case []interface{}:
@@ -83,7 +83,7 @@ func formatJSONObject(obj map[string]interface{}, indent int, currentIndent int,
 
 	for i, key := range keys {
 		val := obj[key]
-		linePrefix := strings.Repeat(" ", nextLevel) + style.Key + "\"" + key + "\"" + resetStyle + ": "
+		linePrefix := strings.Repeat(" ", nextLevel) + style.Key + "\"" + key + "\"" + ResetStyle + ": "
 		valueStr := formatJSONValue(val, indent, nextLevel, style)
 
 		sb.WriteString(linePrefix + valueStr)
@@ -123,3 +123,43 @@ func formatJSONArray(arr []interface{}, indent int, currentIndent int, style *St
 	sb.WriteString(strings.Repeat(" ", currentIndent) + "]")
 	return sb.String()
 }
+
+// CompactJSON formats the object on a single line
+func CompactJSON(obj map[string]interface{}, style *Style) string {
+	return formatCompactValue(obj, style)
+}
+
+func formatCompactValue(value interface{}, style *Style) string {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for key := range v {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		var sb strings.Builder
+		sb.WriteString("{")
+		for i, key := range keys {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(style.Key + "\"" + key + "\"" + ResetStyle + ": " + formatCompactValue(v[key], style))
+		}
+		sb.WriteString("}")
+		return sb.String()
+	case []interface{}:
+		var sb strings.Builder
+		sb.WriteString("[")
+		for i, val := range v {
+			if i > 0 {
+				sb.WriteString(", ")
+			}
+			sb.WriteString(formatCompactValue(val, style))
+		}
+		sb.WriteString("]")
+		return sb.String()
+	default:
+		return formatJSONValue(v, 0, 0, style)
+	}
+}
@@ -11,8 +11,16 @@ type keyMap struct {
 	PageEnd        key.Binding
 	Filter         key.Binding
 	ReturnedFields key.Binding
+	Transforms     key.Binding
+	Display        key.Binding
+	Select         key.Binding
 	Copy           key.Binding
 	View           key.Binding
+	Search         key.Binding
+	SearchBack     key.Binding
+	NextMatch      key.Binding
+	PrevMatch      key.Binding
+	Window         key.Binding
 }
 
 var keys = keyMap{
@@ -48,6 +56,18 @@ var keys = keyMap{
 		key.WithKeys("r"),
 		key.WithHelp("r", "returned fields"),
 	),
+	Transforms: key.NewBinding(
+		key.WithKeys("t"),
+		key.WithHelp("t", "transforms"),
+	),
+	Display: key.NewBinding(
+		key.WithKeys("m"),
+		key.WithHelp("m", "compact/table/expanded"),
+	),
+	Select: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter/click", "select"),
+	),
 	Copy: key.NewBinding(
 		key.WithKeys("c"),
 		key.WithHelp("right click", "copy"),
@@ -56,14 +76,165 @@ var keys = keyMap{
 		key.WithKeys("v"),
 		key.WithHelp("v", "list views"),
 	),
+	Search: key.NewBinding(
+		key.WithKeys("/"),
+		key.WithHelp("/", "search"),
+	),
+	SearchBack: key.NewBinding(
+		key.WithKeys("?"),
+		key.WithHelp("?", "search backward"),
+	),
+	NextMatch: key.NewBinding(
+		key.WithKeys("n"),
+		key.WithHelp("n", "next match"),
+	),
+	PrevMatch: key.NewBinding(
+		key.WithKeys("N"),
+		key.WithHelp("N", "prev match"),
+	),
+	Window: key.NewBinding(
+		key.WithKeys("w"),
+		key.WithHelp("w", "time window"),
+	),
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.ReturnedFields, k.Filter, k.View, k.Copy, k.Esc, k.Quit}
+	return []key.Binding{k.ReturnedFields, k.Filter, k.Transforms, k.Display, k.Search, k.NextMatch, k.PrevMatch, k.View, k.Window, k.Select, k.Copy, k.Esc, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding { return nil }
 
+// selectionKeyMap are the keys of the selected entry
+type selectionKeyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	Details     key.Binding
+	DetailsUp   key.Binding
+	DetailsDown key.Binding
+	Tree        key.Binding
+	Copy        key.Binding
+	CopyRaw     key.Binding
+	CopyField   key.Binding
+	Edit        key.Binding
+	Unselect    key.Binding
+	Quit        key.Binding
+}
+
+var selectionKeys = selectionKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/↓", "move"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Details: key.NewBinding(
+		key.WithKeys("tab", "enter"),
+		key.WithHelp("tab/enter", "details"),
+	),
+	DetailsUp: key.NewBinding(
+		key.WithKeys("pgup"),
+		key.WithHelp("pgup/pgdown", "scroll details"),
+	),
+	DetailsDown: key.NewBinding(
+		key.WithKeys("pgdown"),
+		key.WithHelp("pgdown", "scroll details down"),
+	),
+	Tree: key.NewBinding(
+		key.WithKeys("T"),
+		key.WithHelp("T", "tree"),
+	),
+	Copy: key.NewBinding(
+		key.WithKeys("c"),
+		key.WithHelp("c", "copy"),
+	),
+	CopyRaw: key.NewBinding(
+		key.WithKeys("y"),
+		key.WithHelp("y", "copy raw"),
+	),
+	CopyField: key.NewBinding(
+		key.WithKeys("C"),
+		key.WithHelp("C", "copy field"),
+	),
+	Edit: key.NewBinding(
+		key.WithKeys("e"),
+		key.WithHelp("e", "open in $EDITOR"),
+	),
+	Unselect: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "unselect"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c", "q"),
+		key.WithHelp("ctrl+c/q", "quit"),
+	),
+}
+
+func (k selectionKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Details, k.Tree, k.Copy, k.CopyRaw, k.CopyField, k.Edit, k.Unselect, k.DetailsUp, k.Quit}
+}
+
+func (k selectionKeyMap) FullHelp() [][]key.Binding { return nil }
+
+// treeKeyMap are the keys of the tree of the selected entry
+type treeKeyMap struct {
+	Up          key.Binding
+	Down        key.Binding
+	Toggle      key.Binding
+	Collapse    key.Binding
+	Expand      key.Binding
+	AddField    key.Binding
+	FilterValue key.Binding
+	Back        key.Binding
+	Quit        key.Binding
+}
+
+var treeKeys = treeKeyMap{
+	Up: key.NewBinding(
+		key.WithKeys("up", "k"),
+		key.WithHelp("↑/↓", "move"),
+	),
+	Down: key.NewBinding(
+		key.WithKeys("down", "j"),
+		key.WithHelp("↓/j", "move down"),
+	),
+	Toggle: key.NewBinding(
+		key.WithKeys(" ", "enter"),
+		key.WithHelp("space", "collapse/expand"),
+	),
+	Collapse: key.NewBinding(
+		key.WithKeys("left", "h"),
+		key.WithHelp("←/→", "collapse/expand"),
+	),
+	Expand: key.NewBinding(
+		key.WithKeys("right", "l"),
+		key.WithHelp("→/l", "expand"),
+	),
+	AddField: key.NewBinding(
+		key.WithKeys("a"),
+		key.WithHelp("a", "add to returned fields"),
+	),
+	FilterValue: key.NewBinding(
+		key.WithKeys("="),
+		key.WithHelp("=", "filter on value"),
+	),
+	Back: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "back"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+func (k treeKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Up, k.Toggle, k.AddField, k.FilterValue, k.Back, k.Quit}
+}
+
+func (k treeKeyMap) FullHelp() [][]key.Binding { return nil }
+
 type textModelKeyMap struct {
 	Back key.Binding
 	Quit key.Binding
@@ -91,7 +262,34 @@ var textModelKeys = textModelKeyMap{
 }
 
 func (k textModelKeyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Run, k.Back, k.Quit}
+	return []key.Binding{k.Run, k.Save, k.Back, k.Quit}
 }
 
 func (k textModelKeyMap) FullHelp() [][]key.Binding { return nil }
+
+type searchKeyMap struct {
+	Confirm key.Binding
+	Cancel  key.Binding
+	Quit    key.Binding
+}
+
+var searchKeys = searchKeyMap{
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "confirm"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+	Quit: key.NewBinding(
+		key.WithKeys("ctrl+c"),
+		key.WithHelp("ctrl+c", "quit"),
+	),
+}
+
+func (k searchKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Confirm, k.Cancel, k.Quit}
+}
+
+func (k searchKeyMap) FullHelp() [][]key.Binding { return nil }
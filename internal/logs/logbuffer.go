@@ -1,6 +1,7 @@
 package logs
 
 import (
+	"sort"
 	"strings"
 
 	"github.com/filipecaixeta/logviewer/internal/logs/pipeline"
@@ -10,13 +11,17 @@ type circularLogBuffer struct {
 	Buffer []pipeline.LogEntry
 	Head   int
 	Tail   int
+	// Highlight returns the text displayed for an entry, when nil the
+	// formatted text is used as is
+	Highlight func(l *pipeline.LogEntry) string
 }
 
 // Adds a new element and returns the one removed
 func (c *circularLogBuffer) Add(t pipeline.LogEntry) (removed *pipeline.LogEntry) {
 	if len(c.Buffer) == cap(c.Buffer) {
 		// Buffer is full
-		removed = &c.Buffer[c.Tail]
+		old := c.Buffer[c.Tail]
+		removed = &old
 		c.Buffer[c.Tail] = t
 		c.Tail = (c.Tail + 1) % cap(c.Buffer)
 		if c.Tail == c.Head {
@@ -33,6 +38,13 @@ func (c *circularLogBuffer) Add(t pipeline.LogEntry) (removed *pipeline.LogEntry
 	return removed
 }
 
+// Clear removes every element
+func (c *circularLogBuffer) Clear() {
+	c.Buffer = c.Buffer[:0]
+	c.Head = 0
+	c.Tail = 0
+}
+
 func (c *circularLogBuffer) First() *pipeline.LogEntry {
 	if len(c.Buffer) == 0 {
 		return nil
@@ -72,15 +84,12 @@ func (c *circularLogBuffer) View(scroll int, height int) string {
 		return ""
 	}
 
-	if l := c.Last(); scroll > l.CumHeight-l.Height {
-		scroll = l.CumHeight - l.Height
-	}
-
-	if f := c.First(); scroll < f.CumHeight-f.Height {
-		scroll = f.CumHeight - f.Height
-	}
-
+	scroll = c.clampScroll(scroll)
 	firstVisible := c.binarySearchFirstVisible(scroll)
+	if firstVisible < 0 {
+		// the last entries are filtered out
+		return strings.Repeat("\n", height)
+	}
 
 	var lineCount int
 	var b strings.Builder
@@ -101,7 +110,7 @@ func (c *circularLogBuffer) View(scroll int, height int) string {
 		// handle cases where the first line of the log is not fully visible
 		// or there is only one line in the log and it's height is greater than the height of the screen
 		if i == firstVisible && (scroll > firstLineOffset || c.Buffer[i].Height > height) {
-			formatted := c.Buffer[i].Formatted
+			formatted := c.formatted(i)
 			n := scroll - firstLineOffset
 			lineHeight := c.Buffer[i].Height - n
 			p := findLinePos(formatted, n)
@@ -120,7 +129,7 @@ func (c *circularLogBuffer) View(scroll int, height int) string {
 
 		// if the line is too long to fit on the screen, find the position of the last \n that fits on the screen
 		if lineCount+c.Buffer[i].Height > height {
-			formatted := c.Buffer[i].Formatted
+			formatted := c.formatted(i)
 			// find the position of the last \n that fits on the screen
 			lineHeight := height - lineCount
 			p := findLinePos(formatted, lineHeight)
@@ -131,7 +140,7 @@ func (c *circularLogBuffer) View(scroll int, height int) string {
 			}
 			break
 		}
-		b.WriteString(c.Buffer[i].Formatted)
+		b.WriteString(c.formatted(i))
 		b.WriteString("\n")
 		lineCount += c.Buffer[i].Height
 	}
@@ -144,11 +153,42 @@ func (c *circularLogBuffer) View(scroll int, height int) string {
 	return b.String()
 }
 
-func (c *circularLogBuffer) GetLogEntryAtScrollOffset(scroll int) *pipeline.LogEntry {
+// clampScroll limits the scroll offset to the lines of the entries in the buffer
+func (c *circularLogBuffer) clampScroll(scroll int) int {
 	if len(c.Buffer) == 0 {
+		return scroll
+	}
+	if l := c.Last(); scroll > l.CumHeight-l.Height {
+		scroll = l.CumHeight - l.Height
+	}
+	if f := c.First(); scroll < f.CumHeight-f.Height {
+		scroll = f.CumHeight - f.Height
+	}
+	return scroll
+}
+
+func (c *circularLogBuffer) formatted(i int) string {
+	if c.Highlight == nil {
+		return c.Buffer[i].Formatted
+	}
+	return c.Highlight(&c.Buffer[i])
+}
+
+// GetLogEntryByIndex returns the entry with the given pipeline index or nil
+// if it is no longer in the buffer
+func (c *circularLogBuffer) GetLogEntryByIndex(index int) *pipeline.LogEntry {
+	f := c.First()
+	if f == nil || index < f.Index {
 		return nil
 	}
+	i := (c.Head + index - f.Index) % cap(c.Buffer)
+	if c.Buffer[i].Index != index {
+		return nil
+	}
+	return &c.Buffer[i]
+}
 
+func (c *circularLogBuffer) GetLogEntryAtScrollOffset(scroll int) *pipeline.LogEntry {
 	logEntryIndex := c.binarySearchFirstVisible(scroll)
 	if logEntryIndex < 0 {
 		return nil
@@ -156,42 +196,57 @@ func (c *circularLogBuffer) GetLogEntryAtScrollOffset(scroll int) *pipeline.LogE
 	return &c.Buffer[logEntryIndex]
 }
 
-func (c *circularLogBuffer) binarySearchFirstVisible(scroll int) int {
-	if l := c.Last(); l != nil && scroll > l.CumHeight {
-		return -1
+// GetLogEntryAtLine returns the entry shown on the y-th line of
+// View(scroll, height), nil when the line is empty
+func (c *circularLogBuffer) GetLogEntryAtLine(scroll int, y int) *pipeline.LogEntry {
+	if len(c.Buffer) == 0 || y < 0 {
+		return nil
 	}
+	return c.GetLogEntryAtScrollOffset(c.clampScroll(scroll) + y)
+}
 
-	start := c.Head
-	end := c.Tail
-	if start == end {
-		return start
+// Step returns the first shown entry after the entry with the given index,
+// or before it when backward. nil when there is none
+func (c *circularLogBuffer) Step(index int, backward bool) *pipeline.LogEntry {
+	f := c.First()
+	if f == nil {
+		return nil
 	}
-
-	var firstVisible int
-	for start != end {
-		var mid int
-		if start < end {
-			mid = (start + (end-start)/2) % cap(c.Buffer)
+	n := c.len()
+	for k := index - f.Index; ; {
+		if backward {
+			k--
 		} else {
-			mid = (start + (cap(c.Buffer)-start+end)/2) % cap(c.Buffer)
+			k++
 		}
-		midEntry := c.Buffer[mid]
-		midCumHeight := midEntry.CumHeight
-		midStartHeight := midCumHeight - midEntry.Height
-
-		if midStartHeight <= scroll && midCumHeight > scroll {
-			firstVisible = mid
-			break
+		if k < 0 || k >= n {
+			return nil
 		}
-
-		if midCumHeight <= scroll {
-			start = (mid + 1) % cap(c.Buffer)
-		} else {
-			end = mid
+		if l := &c.Buffer[(c.Head+k)%cap(c.Buffer)]; l.Show {
+			return l
 		}
 	}
+}
 
-	return firstVisible
+// len returns the number of entries in the buffer
+func (c *circularLogBuffer) len() int {
+	if len(c.Buffer) == 0 {
+		return 0
+	}
+	return (c.Tail-c.Head+cap(c.Buffer)-1)%cap(c.Buffer) + 1
+}
+
+// binarySearchFirstVisible returns the position in the buffer of the first
+// shown entry that ends after the scroll offset, -1 when there is none
+func (c *circularLogBuffer) binarySearchFirstVisible(scroll int) int {
+	n := c.len()
+	k := sort.Search(n, func(k int) bool {
+		return c.Buffer[(c.Head+k)%cap(c.Buffer)].CumHeight > scroll
+	})
+	if k == n {
+		return -1
+	}
+	return (c.Head + k) % cap(c.Buffer)
 }
 
 // findLinePos finds the position of the first caracter after the n-th line
@@ -3,19 +3,25 @@ package logs
 import (
 	"context"
 	"fmt"
+	"os"
+	"reflect"
 	"strings"
+	"time"
 
 	"github.com/filipecaixeta/logviewer/internal/common"
 	"github.com/filipecaixeta/logviewer/internal/config"
 	"github.com/filipecaixeta/logviewer/internal/logs/pipeline"
 	"github.com/filipecaixeta/logviewer/internal/logs/viewlist"
+	"github.com/filipecaixeta/logviewer/internal/source"
+	"github.com/filipecaixeta/logviewer/internal/source/stdin"
 	"github.com/filipecaixeta/logviewer/internal/state"
 
 	"github.com/charmbracelet/bubbles/help"
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/textarea"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
-	"golang.design/x/clipboard"
+	"github.com/charmbracelet/lipgloss"
 )
 
 const (
@@ -24,8 +30,9 @@ const (
 )
 
 type Model struct {
-	// lChan is the channel that receives log messages from the streaming API
-	lChan      chan string
+	// lChan is the channel that receives log messages from the streaming API,
+	// every reload streams to a new one
+	lChan      chan source.LogLine
 	logEntries circularLogBuffer
 
 	scrollOffset int
@@ -37,6 +44,45 @@ type Model struct {
 	textModel     textarea.Model
 	help          help.Model
 
+	search      logSearch
+	searchInput textinput.Model
+	// searchOrigin is the scroll position when the search prompt was opened,
+	// it is restored if the search is cancelled
+	searchOrigin     int
+	searchOriginAuto bool
+
+	// windowInput is the prompt to reload the logs with another time window
+	windowInput textinput.Model
+	windowErr   string
+
+	// selected is the Index of the selected entry, 0 when there is none.
+	// details shows all its fields in a pane next to the logs
+	selected      int
+	details       bool
+	detailsOffset int
+	// fieldInput is the prompt of the field of the selected entry to copy
+	fieldInput textinput.Model
+	fieldErr   string
+	// tree focuses the tree of the selected entry in the details pane,
+	// treeCursor is the path of its line under the cursor. The collapsed
+	// paths stay collapsed for every entry
+	tree       bool
+	treeCursor string
+	collapsed  map[string]bool
+
+	// the layout of the last render, to find the entry under the mouse
+	viewStart    int
+	logHeight    int
+	headerHeight int
+
+	// sourceErr is the error returned by the source, the entries already
+	// read are kept. notice is shown until the next key is pressed
+	sourceErr  error
+	notice     string
+	evalErrors evalErrors
+	// transformsErr is the error parsing the transforms being edited
+	transformsErr error
+
 	viewList *viewlist.Model
 
 	pipeline *pipeline.LogPipeline
@@ -44,31 +90,65 @@ type Model struct {
 	common *common.Common
 	ctx    context.Context
 	cancel context.CancelFunc
+	// target streams the items selected when the logs were opened,
+	// the reloads stream them even if the selection changed since
+	target source.Stream
+	// stream counts the reloads, the lines of the previous streams are dropped
+	stream int
+	// dropped is the number of streams being reconnected, ended is true
+	// once the source stopped streaming
+	dropped int
+	ended   bool
+	// lastLine is when the last line was received, the last entry
+	// only waits for more lines for the multiline timeout
+	lastLine time.Time
 }
 
-type LogMsg string
+// StreamEndedMsg is sent when the source stopped streaming the logs
+type StreamEndedMsg struct {
+	stream int
+}
+
+var (
+	connectedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#a6e22e"))
+	reconnectingStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#e6db74"))
+)
+
+type LogMsg struct {
+	source.LogLine
+	stream int
+}
 
 func New(c *common.Common) *Model {
 	m := &Model{
-		lChan:      make(chan string),
-		logEntries: circularLogBuffer{Buffer: make([]pipeline.LogEntry, 0, 20000)},
-		help:       help.New(),
-		common:     c,
-		autoScroll: true,
-		textModel:  textarea.New(),
-		viewList:   viewlist.New(c),
+		lChan:       make(chan source.LogLine),
+		logEntries:  circularLogBuffer{Buffer: make([]pipeline.LogEntry, 0, 20000)},
+		help:        help.New(),
+		common:      c,
+		autoScroll:  true,
+		textModel:   textarea.New(),
+		searchInput: textinput.New(),
+		windowInput: textinput.New(),
+		fieldInput:  textinput.New(),
+		collapsed:   make(map[string]bool),
+		viewList:    viewlist.New(c),
 	}
+	m.logEntries.Highlight = m.highlight
 	c.AddWindowResizeEventListener(m)
 
-	var err error
-	m.pipeline, err = pipeline.New(nil, uint(c.Width))
-	if err != nil {
-		panic(err)
-	}
+	// without a view there is nothing to compile
+	m.pipeline, _ = pipeline.New(nil, uint(c.Width))
 
 	m.textModel.SetWidth(m.common.Width)
 	m.textModel.SetHeight(textModelHeight)
 	m.textModel.Blur()
+	m.windowInput.Prompt = "window: "
+	m.windowInput.Placeholder = "since=15m until=2024-05-01T10:00:00Z tail=500"
+	m.fieldInput.Prompt = "copy field: "
+	m.fieldInput.Placeholder = "msg, request.id, meta.pod"
+	// stdin can't be read again
+	_, isStdin := c.Src.(*stdin.Stdin)
+	keys.Window.SetEnabled(!isStdin)
 
 	return m
 }
@@ -76,35 +156,123 @@ func New(c *common.Common) *Model {
 func (m *Model) Init() tea.Cmd {
 	ctx := context.Background()
 	m.ctx, m.cancel = context.WithCancel(ctx)
+	m.target = m.common.Src.Logs
+	if src, ok := m.common.Src.(source.TargetSource); ok {
+		m.target = src.Target()
+	}
 	viewlist.CurrentView = nil
 	viewlist.DisplayedView = config.View{}
-	return tea.Batch(m.common.Src.Logs(ctx, m.common.StateChan, m.lChan), m.viewList.Init())
+	m.selectView()
+	return tea.Batch(m.streamLogs(), m.viewList.Init())
+}
+
+// selectView applies the view whose selector best matches the
+// metadata of the source, if the source knows it
+func (m *Model) selectView() {
+	src, ok := m.common.Src.(source.MetaSource)
+	if !ok {
+		return
+	}
+	view, reason, err := viewlist.Best(m.common.Cfg.Views, src.Meta())
+	if err != nil {
+		m.notice = err.Error()
+	}
+	m.viewList.SetAuto(view, reason)
+	if view == nil {
+		return
+	}
+	viewlist.CurrentView = view
+	viewlist.DisplayedView = *view
+	// the errors are shown in the notification area
+	_ = m.pipeline.SetView(view)
+}
+
+// streamLogs runs the Logs command of the source, it sends a
+// StreamEndedMsg when the command returns without an error or
+// when the stream was replaced by a reload
+func (m *Model) streamLogs() tea.Cmd {
+	logs := m.target(m.ctx, m.common.LogOptions, m.common.StateChan, m.lChan)
+	ctx, stream := m.ctx, m.stream
+	return func() tea.Msg {
+		if msg := logs(); msg != nil && ctx.Err() == nil {
+			return msg
+		}
+		return StreamEndedMsg{stream: stream}
+	}
 }
 
 func (m *Model) Close() {
 	m.cancel()
 }
 
+// reload streams the logs of the same target again with other options,
+// the entries of the previous stream are dropped
+func (m *Model) reload(opts source.LogOptions) tea.Cmd {
+	m.cancel()
+	m.ctx, m.cancel = context.WithCancel(context.Background())
+	m.stream++
+	// the new stream has its own channel, so the reader of the previous
+	// one still waiting for a line can't take the first line of the new one
+	m.lChan = make(chan source.LogLine)
+	m.common.LogOptions = opts
+	m.dropped = 0
+	m.ended = false
+	m.sourceErr = nil
+
+	m.unselect()
+	m.logEntries.Clear()
+	m.pipeline.Reset()
+	m.evalErrors = evalErrors{}
+	m.search.refresh(&m.logEntries)
+	m.scrollOffset = 0
+	m.autoScroll = true
+
+	return tea.Batch(m.streamLogs(), m.handleLogEntry())
+}
+
+func (m *Model) openWindow() tea.Cmd {
+	m.windowErr = ""
+	m.windowInput.SetValue(m.common.LogOptions.String())
+	m.windowInput.CursorEnd()
+	return m.windowInput.Focus()
+}
+
+func (m *Model) updateWindowInput(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, searchKeys.Confirm):
+		opts, err := source.ParseLogWindow(m.windowInput.Value(), m.common.LogOptions, time.Now())
+		if err != nil {
+			m.windowErr = err.Error()
+			return nil
+		}
+		m.windowInput.Blur()
+		return m.reload(opts)
+	case key.Matches(msg, searchKeys.Cancel):
+		m.windowInput.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.windowInput, cmd = m.windowInput.Update(msg)
+	return cmd
+}
+
 func (m *Model) updateFilterTextModel(msg tea.KeyMsg) tea.Cmd {
 	switch {
 	case key.Matches(msg, textModelKeys.Save):
+		// the filter is saved even if it doesn't compile,
+		// the error stays in the notification area until it is fixed
 		m.textModel.Blur()
 		viewlist.DisplayedView.Filter = m.textModel.Value()
-		if err := m.pipeline.SetFilter(viewlist.DisplayedView.Filter); err != nil {
-			fmt.Printf("err: %v\n", err)
-		}
-		// TODO
-		// call function to save the config file
-		viewlist.CurrentView.Filter = viewlist.DisplayedView.Filter
+		_ = m.pipeline.SetFilter(viewlist.DisplayedView.Filter)
+		m.saveView(func(v *config.View) { v.Filter = viewlist.DisplayedView.Filter })
 
-		m.logEntries.RunPipeline(m.pipeline.RunFilterChanged)
+		m.runPipeline(m.pipeline.RunFilterChanged)
 		return nil
 	case key.Matches(msg, textModelKeys.Run):
 		viewlist.DisplayedView.Filter = m.textModel.Value()
-		if err := m.pipeline.SetFilter(viewlist.DisplayedView.Filter); err != nil {
-			fmt.Printf("err: %v\n", err)
-		}
-		m.logEntries.RunPipeline(m.pipeline.RunFilterChanged)
+		_ = m.pipeline.SetFilter(viewlist.DisplayedView.Filter)
+		m.runPipeline(m.pipeline.RunFilterChanged)
 		return nil
 	case key.Matches(msg, textModelKeys.Back):
 		m.textModel.Blur()
@@ -130,24 +298,48 @@ func (m *Model) updateReturnedFieldsTextModel(msg tea.KeyMsg) tea.Cmd {
 	case key.Matches(msg, textModelKeys.Save):
 		m.textModel.Blur()
 		viewlist.DisplayedView.ReturnedFields = returnedFields
-		if err := m.pipeline.SetReturnedFields(returnedFields); err != nil {
-			fmt.Printf("err: %v\n", err)
-		}
-		// TODO
-		// call function to save the config file
-		viewlist.CurrentView.ReturnedFields = returnedFields
+		_ = m.pipeline.SetReturnedFields(returnedFields)
+		m.saveView(func(v *config.View) { v.ReturnedFields = returnedFields })
 
-		m.logEntries.RunPipeline(m.pipeline.RunReturnedFieldsChanged)
+		m.runPipeline(m.pipeline.RunReturnedFieldsChanged)
 		return nil
 	case key.Matches(msg, textModelKeys.Run):
 		viewlist.DisplayedView.ReturnedFields = returnedFields
-		if err := m.pipeline.SetReturnedFields(returnedFields); err != nil {
-			fmt.Printf("err: %v\n", err)
+		_ = m.pipeline.SetReturnedFields(returnedFields)
+		m.runPipeline(m.pipeline.RunReturnedFieldsChanged)
+		return nil
+	case key.Matches(msg, textModelKeys.Back):
+		m.textModel.Blur()
+	default:
+		var cmd tea.Cmd
+		m.textModel, cmd = m.textModel.Update(msg)
+		return cmd
+	}
+	return nil
+}
+
+// updateTransformsTextModel edits the transforms of the view,
+// one per line as field = expression
+func (m *Model) updateTransformsTextModel(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, textModelKeys.Save), key.Matches(msg, textModelKeys.Run):
+		transforms, err := parseTransforms(m.textModel.Value())
+		m.transformsErr = err
+		if err != nil {
+			return nil
+		}
+		viewlist.DisplayedView.Transforms = transforms
+		_ = m.pipeline.SetTransforms(transforms)
+		if key.Matches(msg, textModelKeys.Save) {
+			m.textModel.Blur()
+			m.saveView(func(v *config.View) { v.Transforms = transforms })
 		}
-		m.logEntries.RunPipeline(m.pipeline.RunReturnedFieldsChanged)
+		// the fields set by the removed transforms are dropped by parsing the lines again
+		m.runPipeline(m.pipeline.RunViewChanged)
 		return nil
 	case key.Matches(msg, textModelKeys.Back):
 		m.textModel.Blur()
+		m.transformsErr = nil
 	default:
 		var cmd tea.Cmd
 		m.textModel, cmd = m.textModel.Update(msg)
@@ -156,23 +348,139 @@ func (m *Model) updateReturnedFieldsTextModel(msg tea.KeyMsg) tea.Cmd {
 	return nil
 }
 
+func formatTransforms(transforms []config.Transform) string {
+	lines := make([]string, len(transforms))
+	for i, t := range transforms {
+		lines[i] = t.Field + " = " + t.Expression
+	}
+	return strings.Join(lines, "\n")
+}
+
+func parseTransforms(text string) ([]config.Transform, error) {
+	var transforms []config.Transform
+	for i, line := range strings.Split(text, "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		field, expression, ok := strings.Cut(line, "=")
+		field, expression = strings.TrimSpace(field), strings.TrimSpace(expression)
+		if !ok || field == "" || expression == "" {
+			return nil, fmt.Errorf("line %d: write the transforms as field = expression", i+1)
+		}
+		transforms = append(transforms, config.Transform{Field: field, Expression: expression})
+	}
+	return transforms, nil
+}
+
+// saveView saves the changes of update to the current view in the config file
+func (m *Model) saveView(update func(v *config.View)) {
+	if err := viewlist.SaveCurrentView(m.common.Cfg, update); err != nil {
+		m.notice = "saving the view: " + err.Error()
+	}
+}
+
+func (m *Model) openSearch(backward bool) tea.Cmd {
+	m.search.clear()
+	m.search.backward = backward
+	m.searchOrigin = m.scrollOffset
+	m.searchOriginAuto = m.autoScroll
+	m.searchInput.Prompt = "/"
+	if backward {
+		m.searchInput.Prompt = "?"
+	}
+	m.searchInput.SetValue("")
+	return m.searchInput.Focus()
+}
+
+func (m *Model) updateSearchInput(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, searchKeys.Confirm):
+		m.searchInput.Blur()
+		return nil
+	case key.Matches(msg, searchKeys.Cancel):
+		m.searchInput.Blur()
+		m.search.clear()
+		m.scrollOffset = m.searchOrigin
+		m.autoScroll = m.searchOriginAuto
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.searchInput, cmd = m.searchInput.Update(msg)
+	if m.searchInput.Value() != m.search.query {
+		// incremental search, every change starts again from where the prompt was opened
+		m.search.query = m.searchInput.Value()
+		m.search.refresh(&m.logEntries)
+		m.scrollOffset = m.searchOrigin
+		m.autoScroll = m.searchOriginAuto
+		if l := m.logEntries.GetLogEntryAtScrollOffset(m.searchOrigin); l != nil {
+			if index, ok := m.search.seek(l.Index); ok {
+				m.scrollToEntry(index)
+			}
+		}
+	}
+	return cmd
+}
+
+// scrollToEntry scrolls the view so the entry with the given index is at the top
+func (m *Model) scrollToEntry(index int) {
+	l := m.logEntries.GetLogEntryByIndex(index)
+	if l == nil {
+		return
+	}
+	m.autoScroll = false
+	m.scrollOffset = l.CumHeight - l.Height
+}
+
+// runPipeline runs f over every entry in the buffer and updates the search
+// matches, since the formatted text may have changed
+func (m *Model) runPipeline(f func(l *pipeline.LogEntry) error) {
+	m.logEntries.RunPipeline(f)
+	m.alignColumns()
+	m.search.refresh(&m.logEntries)
+	m.evalErrors.refresh(&m.logEntries)
+}
+
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.KeyMsg:
+		m.notice = ""
 		if m.textModel.Focused() && m.textareaTitle == "Filter" {
 			return m, m.updateFilterTextModel(msg)
 		} else if m.textModel.Focused() && m.textareaTitle == "Returned Fields" {
 			return m, m.updateReturnedFieldsTextModel(msg)
+		} else if m.textModel.Focused() && m.textareaTitle == "Transforms" {
+			return m, m.updateTransformsTextModel(msg)
+		} else if m.searchInput.Focused() {
+			return m, m.updateSearchInput(msg)
+		} else if m.windowInput.Focused() {
+			return m, m.updateWindowInput(msg)
+		} else if m.fieldInput.Focused() {
+			return m, m.updateFieldInput(msg)
 		}
 		if m.viewList.Visible {
 			var cmd tea.Cmd
 			_, cmd = m.viewList.Update(msg)
 			return m, cmd
 		}
+		if m.tree {
+			if cmd, ok := m.updateTree(msg); ok {
+				return m, cmd
+			}
+		}
+		if m.selected != 0 {
+			if cmd, ok := m.updateSelection(msg); ok {
+				return m, cmd
+			}
+		}
 		switch {
 		case key.Matches(msg, keys.Quit):
 			return m, tea.Quit
 		case key.Matches(msg, keys.Esc):
+			if m.search.active() {
+				m.search.clear()
+				return m, nil
+			}
 			m.common.SetState(state.StateBrose)
 			return m, nil
 		case key.Matches(msg, keys.Up):
@@ -193,40 +501,108 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.textareaTitle = "Returned Fields"
 			m.textModel.SetValue(strings.Join(viewlist.DisplayedView.ReturnedFields, ", "))
 			return m, textarea.Blink
+		case key.Matches(msg, keys.Transforms):
+			m.textModel.Focus()
+			m.textareaTitle = "Transforms"
+			m.transformsErr = nil
+			m.textModel.SetValue(formatTransforms(viewlist.DisplayedView.Transforms))
+			return m, textarea.Blink
+		case key.Matches(msg, keys.Select):
+			m.selectOnScreen()
+		case key.Matches(msg, keys.Display):
+			m.pipeline.SetDisplay(m.pipeline.Display().Next())
+			m.runPipeline(m.pipeline.RunWidthChanged)
 		case key.Matches(msg, keys.View):
 			m.viewList.Visible = true
+		case key.Matches(msg, keys.Search):
+			return m, m.openSearch(false)
+		case key.Matches(msg, keys.SearchBack):
+			return m, m.openSearch(true)
+		case key.Matches(msg, keys.NextMatch):
+			if index, ok := m.search.step(false); ok {
+				m.scrollToEntry(index)
+			}
+		case key.Matches(msg, keys.PrevMatch):
+			if index, ok := m.search.step(true); ok {
+				m.scrollToEntry(index)
+			}
+		case key.Matches(msg, keys.Window):
+			return m, m.openWindow()
 		}
 	case tea.MouseMsg:
-		switch msg.Button {
-		case tea.MouseButtonWheelUp:
+		inDetails := m.details && msg.X >= m.logsWidth()
+		switch {
+		case msg.Button == tea.MouseButtonWheelUp && inDetails:
+			m.scrollDetails(-1)
+		case msg.Button == tea.MouseButtonWheelDown && inDetails:
+			m.scrollDetails(1)
+		case msg.Button == tea.MouseButtonWheelUp:
 			m.scrollUp(1)
-		case tea.MouseButtonWheelDown:
+		case msg.Button == tea.MouseButtonWheelDown:
 			m.scrollDown(1)
-		case tea.MouseButtonRight:
-			m.copyToClipboard(msg.Y)
+		case msg.Action != tea.MouseActionPress:
+		case msg.Button == tea.MouseButtonLeft:
+			m.selectEntry(m.entryAt(msg.X, msg.Y))
+		case msg.Button == tea.MouseButtonRight:
+			if l := m.entryAt(msg.X, msg.Y); l != nil {
+				m.copyToClipboard(m.formatEntry(l))
+			}
+		}
+	case editorClosedMsg:
+		os.Remove(msg.file)
+		if msg.err != nil {
+			m.notice = fmt.Sprintf("editor: %v", msg.err)
 		}
+		return m, nil
 	case tea.WindowSizeMsg:
 		if msg.Width != m.width {
 			m.width = msg.Width
 			m.textModel.SetWidth(m.common.Width)
-			_ = m.pipeline.SetWidth(uint(msg.Width))
-			m.logEntries.RunPipeline(m.pipeline.RunWidthChanged)
+			_ = m.pipeline.SetWidth(uint(m.logsWidth()))
+			m.runPipeline(m.pipeline.RunWidthChanged)
 		}
 		return m, nil
 	case state.State:
 		switch msg {
 		case state.StateLoadView:
+			// the current view is nil when it was deleted
 			view := viewlist.CurrentView
+			if view == nil {
+				view = &config.View{}
+			}
+			regroup := !reflect.DeepEqual(viewlist.DisplayedView.Multiline, view.Multiline)
 			viewlist.DisplayedView = *view
-			if err := m.pipeline.SetView(view); err != nil {
-				fmt.Printf("err: %v\n", err)
+			// the errors are shown in the notification area
+			_ = m.pipeline.SetView(view)
+			if regroup {
+				m.regroup()
+			} else {
+				m.runPipeline(m.pipeline.RunViewChanged)
 			}
-			m.logEntries.RunPipeline(m.pipeline.RunViewChanged)
 			m.common.State = state.StateLogs
 			return m, m.common.HandleStateChange()
 		}
+	case error:
+		// the entries already read are kept, the view can still be changed
+		m.sourceErr = msg
+		m.ended = true
+		return m, nil
+	case StreamEndedMsg:
+		if msg.stream == m.stream {
+			m.ended = true
+		}
+		return m, nil
 	case LogMsg:
-		if msg != "" {
+		if msg.stream != m.stream {
+			return m, nil
+		}
+		switch msg.Status {
+		case source.StreamDropped:
+			m.dropped++
+		case source.StreamReconnected, source.StreamClosed:
+			m.dropped = max(0, m.dropped-1)
+		}
+		if msg.Text != "" {
 			m.handleLogMsg(msg)
 		}
 		return m, m.handleLogEntry()
@@ -234,23 +610,6 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, nil
 }
 
-func (m *Model) copyToClipboard(y int) {
-	l := m.logEntries.GetLogEntryAtScrollOffset(m.scrollOffset + y)
-	err := clipboard.Init()
-	if err != nil {
-		fmt.Printf("err: %v\n", err)
-	}
-	if err == nil && l != nil {
-		lCopy := *l
-		t := pipeline.LogFormat{
-			ReturnedFields: viewlist.DisplayedView.ReturnedFields,
-		}
-		_ = t.RunReturnedFieldsAndFormat(&lCopy)
-		// fmt.Printf("lCopy: %+v\n", lCopy)
-		clipboard.Write(clipboard.FmtText, []byte(lCopy.Formatted))
-	}
-}
-
 func (m *Model) scrollUp(n int) {
 	m.autoScroll = false
 	var minOffset int
@@ -271,19 +630,57 @@ func (m *Model) scrollDown(n int) {
 func (m *Model) View() string {
 	var helpView string
 
+	// the status is on the help line, the help is truncated to fit
+	statusView := m.statusView() + "  "
+	m.help.Width = max(0, m.common.Width-lipgloss.Width(statusView))
+
 	height := m.common.Height - helpHeight
 
 	var footerView string
 	if m.textModel.Focused() {
-		footerView = config.TitleBorderStyle.Width(m.common.Width).Render(m.textareaTitle) + "\n" + m.textModel.View() + "\n"
-		height -= textModelHeight + 3
+		inlineErr := m.inlineErr()
+		footerView = config.TitleBorderStyle.Width(m.common.Width).Render(m.textareaTitle) + "\n" + m.textModel.View() + "\n" + inlineErr
+		height -= textModelHeight + 3 + lipgloss.Height(inlineErr) - 1
 		helpView = m.help.View(textModelKeys)
 	} else if m.viewList.Visible {
-		height -= m.viewList.Height + 1
 		footerView = m.viewList.View()
-		helpView = m.help.View(viewlist.Keys)
+		height -= lipgloss.Height(footerView) - 1
+		if m.viewList.Editing() {
+			helpView = m.help.View(viewlist.PromptKeys)
+		} else {
+			helpView = m.help.View(viewlist.Keys)
+		}
+	} else if m.searchInput.Focused() {
+		height--
+		footerView = m.searchInput.View() + "  " + config.ListStyle.Render(m.search.count()) + "\n"
+		helpView = m.help.View(searchKeys)
+	} else if m.windowInput.Focused() {
+		height--
+		footerView = m.windowInput.View() + "  " + config.ListStyle.Render(m.windowErr) + "\n"
+		helpView = m.help.View(searchKeys)
+	} else if m.fieldInput.Focused() {
+		height--
+		footerView = m.fieldInput.View() + "  " + config.ListStyle.Render(m.fieldErr) + "\n"
+		helpView = m.help.View(searchKeys)
+	} else if m.search.active() {
+		height--
+		footerView = config.ListStyle.Render(m.search.status()) + "\n"
+		helpView = m.keysHelp()
 	} else {
-		helpView = m.help.View(keys)
+		helpView = m.keysHelp()
+	}
+
+	if notifications := m.notifications(); len(notifications) > 0 {
+		height -= len(notifications)
+		footerView = strings.Join(notifications, "\n") + "\n" + footerView
+	}
+
+	var headerView string
+	m.headerHeight = 0
+	if header := m.pipeline.Header(); header != "" {
+		height--
+		headerView = header + "\n"
+		m.headerHeight = 1
 	}
 
 	m.maxScroll = m.logEntries.Height() - height
@@ -292,28 +689,133 @@ func (m *Model) View() string {
 	}
 
 	start := max(0, min(m.maxScroll, m.scrollOffset))
+	m.viewStart = m.logEntries.clampScroll(start)
+	m.logHeight = height
+
+	logsView := m.logEntries.View(start, height)
+	if l := m.selectedEntry(); m.details && l != nil {
+		logsView = m.withDetails(logsView, l, height)
+	}
+
+	return headerView + logsView + footerView + statusView + helpView
+}
+
+// keysHelp returns the help of the keys of the logs, or of
+// the selected entry or its tree when they are focused
+func (m *Model) keysHelp() string {
+	if m.tree {
+		return m.help.View(treeKeys)
+	}
+	if m.selected != 0 {
+		return m.help.View(selectionKeys)
+	}
+	return m.help.View(keys)
+}
 
-	return m.logEntries.View(start, height) + footerView + helpView
+// statusView shows whether the logs are still streamed
+func (m *Model) statusView() string {
+	switch {
+	case m.ended:
+		return config.ListStyle.Render("● ended")
+	case m.dropped > 0:
+		return reconnectingStyle.Render("● reconnecting")
+	default:
+		return connectedStyle.Render("● connected")
+	}
 }
 
 func (m *Model) handleLogEntry() tea.Cmd {
+	ctx, stream, lChan := m.ctx, m.stream, m.lChan
 	return func() tea.Msg {
-		l := <-m.lChan
-		return LogMsg(l)
+		select {
+		case l := <-lChan:
+			return LogMsg{LogLine: l, stream: stream}
+		case <-ctx.Done():
+			return nil
+		}
 	}
 }
 
 func (m *Model) handleLogMsg(msg LogMsg) tea.Msg {
+	now := time.Now()
+	m.addLine(msg.LogLine, now.Sub(m.lastLine))
+	m.lastLine = now
+	if m.autoScroll {
+		m.scrollOffset = m.maxScroll
+	}
+	return nil
+}
+
+// addLine adds a line to the buffer, as a new entry or merged into the
+// last one when it continues it. elapsed is the time since the last line
+func (m *Model) addLine(line source.LogLine, elapsed time.Duration) {
+	if last := m.logEntries.Last(); !line.Marker && m.pipeline.Multiline.Continues(last, line.Text, line.Meta, elapsed) {
+		old := *last
+		last.Raw += "\n" + line.Text
+		_ = m.pipeline.RunLastChanged(last)
+		m.search.update(last)
+		m.evalErrors.evict(&old)
+		m.evalErrors.add(last)
+		if m.alignColumns() {
+			m.search.refresh(&m.logEntries)
+		}
+		return
+	}
+
 	l := pipeline.LogEntry{
-		Raw: string(msg),
+		Raw:    line.Text,
+		Meta:   line.Meta,
+		Marker: line.Marker,
 	}
 	_ = m.pipeline.Run(&l)
 	old := m.logEntries.Add(l)
 	if old != nil {
+		if old.Index == m.selected {
+			m.unselect()
+		}
 		m.scrollOffset -= old.Height
+		m.search.evict(m.logEntries.First())
+		m.evalErrors.evict(old)
 	}
-	if m.autoScroll {
-		m.scrollOffset = m.maxScroll
+	m.search.add(m.logEntries.Last())
+	m.evalErrors.add(m.logEntries.Last())
+	if m.alignColumns() {
+		m.search.refresh(&m.logEntries)
 	}
-	return nil
+}
+
+// alignColumns formats the entries again when the columns of the
+// table grew, so the rows formatted before are aligned with the new ones
+func (m *Model) alignColumns() bool {
+	if !m.pipeline.ColumnsChanged() {
+		return false
+	}
+	m.pipeline.Reset()
+	m.logEntries.RunPipeline(m.pipeline.RunWidthChanged)
+	// every entry was measured, the columns don't grow again
+	m.pipeline.ColumnsChanged()
+	return true
+}
+
+// regroup splits the entries in their lines and adds them again,
+// so they are merged with the multiline config of the view
+func (m *Model) regroup() {
+	var lines []source.LogLine
+	m.logEntries.RunPipeline(func(l *pipeline.LogEntry) error {
+		for _, text := range strings.Split(l.Raw, "\n") {
+			lines = append(lines, source.LogLine{Text: text, Meta: l.Meta, Marker: l.Marker})
+		}
+		return nil
+	})
+
+	m.unselect()
+	m.logEntries.Clear()
+	m.pipeline.Reset()
+	m.evalErrors = evalErrors{}
+	m.search.refresh(&m.logEntries)
+	for _, line := range lines {
+		m.addLine(line, 0)
+	}
+	m.scrollOffset = 0
+	m.autoScroll = true
 }
@@ -0,0 +1,117 @@
+package logs
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/filipecaixeta/logviewer/internal/logs/pipeline"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+)
+
+// maxInlineErrLines is the number of lines of a compile error shown under
+// the text area, expr errors point at the position of the error
+const maxInlineErrLines = 4
+
+var errStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#f92672"))
+
+// evalErrors counts the entries of the buffer whose
+// expressions failed and keeps the last error
+type evalErrors struct {
+	count int
+	last  error
+}
+
+func (e *evalErrors) add(l *pipeline.LogEntry) {
+	if err := l.Err(); err != nil {
+		e.count++
+		e.last = err
+	}
+}
+
+func (e *evalErrors) evict(l *pipeline.LogEntry) {
+	if l.Err() != nil {
+		e.count = max(0, e.count-1)
+	}
+}
+
+// refresh counts the errors again, it must be called every time
+// the pipeline runs over the entries
+func (e *evalErrors) refresh(c *circularLogBuffer) {
+	e.count = 0
+	e.last = nil
+	c.RunPipeline(func(l *pipeline.LogEntry) error {
+		e.add(l)
+		return nil
+	})
+}
+
+func (e *evalErrors) String() string {
+	if e.count == 0 {
+		return ""
+	}
+	entries := "entries"
+	if e.count == 1 {
+		entries = "entry"
+	}
+	return fmt.Sprintf("%d %s failed: %v", e.count, entries, e.last)
+}
+
+// notifications returns the lines of the notification area, the errors
+// of the source, of the view and of the entries, one line each
+func (m *Model) notifications() []string {
+	var lines []string
+	add := func(text string) {
+		if text == "" {
+			return
+		}
+		// only the first line, the whole error is shown while editing
+		text, _, _ = strings.Cut(text, "\n")
+		lines = append(lines, errStyle.Render(runewidth.Truncate(text, m.common.Width, "…")))
+	}
+	if m.notice != "" {
+		add(m.notice)
+	}
+	if m.sourceErr != nil {
+		add("source: " + m.sourceErr.Error())
+	}
+	if err := m.pipeline.CompileErr(); err != nil {
+		for _, e := range strings.Split(err.Error(), "\n") {
+			// the lines of expr errors pointing at the position are left out,
+			// the filter error is under the text area while it is edited
+			if strings.HasPrefix(e, " | ") || (strings.HasPrefix(e, "filter: ") && m.inlineErr() != "") {
+				continue
+			}
+			add(e)
+		}
+	}
+	add(m.evalErrors.String())
+	return lines
+}
+
+// inlineErr returns the error of the expression being edited,
+// shown under the text area, empty once the text area is closed
+func (m *Model) inlineErr() string {
+	if !m.textModel.Focused() {
+		return ""
+	}
+	var err error
+	switch m.textareaTitle {
+	case "Filter":
+		err = m.pipeline.FilterErr()
+	case "Transforms":
+		err = m.transformsErr
+	}
+	if err == nil {
+		return ""
+	}
+	lines := strings.Split(err.Error(), "\n")
+	if len(lines) > maxInlineErrLines {
+		lines = lines[:maxInlineErrLines]
+	}
+	for i := range lines {
+		lines[i] = runewidth.Truncate(lines[i], m.common.Width, "…")
+	}
+	return errStyle.Render(strings.Join(lines, "\n")) + "\n"
+}
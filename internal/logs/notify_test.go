@@ -0,0 +1,58 @@
+package logs
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/filipecaixeta/logviewer/internal/common"
+	"github.com/filipecaixeta/logviewer/internal/config"
+	"github.com/filipecaixeta/logviewer/internal/source/fake"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+func newTestModel() *Model {
+	cfg := &config.Config{Command: "test"}
+	c := common.New(cfg)
+	c.Src = fake.New(cfg)
+	c.Width, c.Height = 120, 40
+	return New(c)
+}
+
+// hasFilterErr reports whether the filter error is in the notification area
+func hasFilterErr(m *Model) bool {
+	for _, line := range m.notifications() {
+		if strings.Contains(line, "filter: ") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFilterErrAfterClosingEditor(t *testing.T) {
+	for _, k := range []tea.KeyType{tea.KeyCtrlS, tea.KeyEsc} {
+		m := newTestModel()
+		m.textModel.Focus()
+		m.textareaTitle = "Filter"
+		m.textModel.SetValue(`json.level ==`)
+		m.updateFilterTextModel(tea.KeyMsg{Type: tea.KeyCtrlR})
+
+		if m.inlineErr() == "" {
+			t.Fatalf("%s: the filter error isn't under the text area", k)
+		}
+		if hasFilterErr(m) {
+			t.Errorf("%s: the filter error is shown twice while editing", k)
+		}
+
+		m.updateFilterTextModel(tea.KeyMsg{Type: k})
+		if m.textModel.Focused() {
+			t.Fatalf("%s: the editor is still open", k)
+		}
+		if m.inlineErr() != "" {
+			t.Errorf("%s: the inline error is shown with the editor closed", k)
+		}
+		if !hasFilterErr(m) {
+			t.Errorf("%s: the filter error isn't in the notification area: %q", k, m.notifications())
+		}
+	}
+}
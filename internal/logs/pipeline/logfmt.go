@@ -0,0 +1,101 @@
+package pipeline
+
+import (
+	"regexp"
+	"strconv"
+)
+
+// jsonNumber matches the numbers as they are written in JSON,
+// so ids like 0042 are kept as strings
+var jsonNumber = regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`)
+
+// parseLogfmt decodes a logfmt line like `level=info msg="started" dur=3ms`.
+// Keys without a value are set to true, unless strict is set, then the line
+// is only decoded if every key has a value, so plain text isn't taken for
+// logfmt. It returns nil if the line isn't logfmt
+func parseLogfmt(line string, strict bool) map[string]interface{} {
+	j := make(map[string]interface{})
+	for i := 0; i < len(line); {
+		if line[i] == ' ' || line[i] == '\t' {
+			i++
+			continue
+		}
+
+		// key, up to the = or a space
+		start := i
+		for i < len(line) && line[i] > ' ' && line[i] != '=' && line[i] != '"' {
+			i++
+		}
+		key := line[start:i]
+		if key == "" {
+			return nil
+		}
+		if i == len(line) || line[i] != '=' {
+			if strict || (i < len(line) && line[i] == '"') {
+				return nil
+			}
+			j[key] = true
+			continue
+		}
+		i++
+
+		// value, quoted or up to the next space
+		if i < len(line) && line[i] == '"' {
+			end := quotedEnd(line, i)
+			if end < 0 {
+				return nil
+			}
+			value, err := strconv.Unquote(line[i:end])
+			if err != nil {
+				return nil
+			}
+			j[key] = value
+			i = end
+			continue
+		}
+		start = i
+		for i < len(line) && line[i] != ' ' && line[i] != '\t' {
+			if line[i] == '"' {
+				return nil
+			}
+			i++
+		}
+		j[key] = logfmtValue(line[start:i])
+	}
+	if len(j) == 0 {
+		return nil
+	}
+	return j
+}
+
+// quotedEnd returns the position after the closing quote of the
+// value starting at i or -1 if it isn't closed
+func quotedEnd(line string, i int) int {
+	for i++; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+	return -1
+}
+
+// logfmtValue converts the values that aren't quoted to the types
+// they would have in JSON, so filters can compare numbers
+func logfmtValue(value string) interface{} {
+	switch {
+	case value == "true":
+		return true
+	case value == "false":
+		return false
+	case value == "null":
+		return nil
+	case jsonNumber.MatchString(value):
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	}
+	return value
+}
@@ -0,0 +1,198 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+)
+
+const (
+	// defaultMultilineTimeout is how long an entry waits for more lines
+	defaultMultilineTimeout = time.Second
+	// defaultMultilineMaxLines is the most lines merged in an entry, so a
+	// start pattern that never matches doesn't merge the whole stream
+	defaultMultilineMaxLines = 500
+)
+
+// multilinePreset holds the continuation patterns of the stack traces of
+// a language. Blank matches the entries holding a trace, the blank lines
+// between the parts of a trace continue them while it isn't over
+type multilinePreset struct {
+	Continuation []string
+	Blank        []string
+}
+
+// multilinePresets are the presets of common languages,
+// used with `presets = ["<name>"]` in the multiline config
+var multilinePresets = map[string]multilinePreset{
+	"java": {
+		Continuation: []string{
+			`^\s+at\s`,
+			`^\s+\.\.\. \d+ (more|common frames omitted)`,
+			`^(Caused by|\s*Suppressed): `,
+			`^[\w$.]+(Exception|Error|Throwable)(: .*)?$`,
+		},
+	},
+	"python": {
+		Continuation: []string{
+			`^\s`,
+			`^Traceback \(most recent call last\):$`,
+			`^(During handling of the above exception|The above exception was the direct cause)`,
+			`^[\w.]+(Error|Exception|Exit|Interrupt|Warning)(: .*)?$`,
+		},
+		// chained exceptions are separated by blank lines
+		Blank: []string{`(?m)^Traceback \(most recent call last\):$`},
+	},
+	"go": {
+		Continuation: []string{
+			`^goroutine \d+ \[.+\]:$`,
+			`^\t`,
+			`^[\w/.-]+\.[\w.*()\[\]{}-]*\(.*\)$`,
+			`^panic\(`,
+			`^created by `,
+			`^\[signal `,
+			`^exit status \d+$`,
+		},
+		// a blank line follows the panic message and separates the goroutines
+		Blank: []string{`(?m)^(panic|fatal error): `},
+	},
+}
+
+// Multiline merges the lines of an entry that spans many lines, like a
+// stack trace, into the entry of its first line
+type Multiline struct {
+	// Start matches the first line of the entries, the lines
+	// that don't match it continue the previous entry
+	Start []*regexp.Regexp
+	// Continuation matches the lines that continue the previous entry
+	Continuation []*regexp.Regexp
+	// Blank matches the entries that blank lines continue
+	Blank []*regexp.Regexp
+	// Indent continues the previous entry with the indented lines
+	Indent bool
+	// Timeout is how long the last entry waits for more lines while streaming
+	Timeout time.Duration
+	// MaxLines is the most lines merged in an entry
+	MaxLines int
+}
+
+// compileMultiline compiles the multiline config of a view, the patterns
+// that don't compile are skipped. It returns nil when the lines aren't merged
+func compileMultiline(cfg *config.Multiline) (*Multiline, error) {
+	if cfg == nil {
+		return nil, nil
+	}
+	ml := &Multiline{Indent: cfg.Indent, Timeout: defaultMultilineTimeout, MaxLines: defaultMultilineMaxLines}
+	var errs []error
+	compile := func(patterns []string) []*regexp.Regexp {
+		var compiled []*regexp.Regexp
+		for _, p := range patterns {
+			re, err := regexp.Compile(p)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			compiled = append(compiled, re)
+		}
+		return compiled
+	}
+	for _, name := range cfg.Presets {
+		preset, ok := multilinePresets[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("unknown preset %q, use java, python or go", name))
+			continue
+		}
+		ml.Continuation = append(ml.Continuation, compile(preset.Continuation)...)
+		ml.Blank = append(ml.Blank, compile(preset.Blank)...)
+	}
+	ml.Start = compile(cfg.Start)
+	ml.Continuation = append(ml.Continuation, compile(cfg.Continuation)...)
+	if cfg.Timeout != "" {
+		timeout, err := time.ParseDuration(cfg.Timeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("timeout: %w", err))
+		} else {
+			ml.Timeout = timeout
+		}
+	}
+	if cfg.MaxLines < 0 {
+		errs = append(errs, errors.New("maxLines must be positive"))
+	} else if cfg.MaxLines > 0 {
+		ml.MaxLines = cfg.MaxLines
+	}
+	var err error
+	if len(errs) > 0 {
+		err = fmt.Errorf("multiline: %w", errors.Join(errs...))
+	}
+	if len(ml.Start) == 0 && len(ml.Continuation) == 0 && !ml.Indent {
+		return nil, err
+	}
+	return ml, err
+}
+
+// Continues reports whether the line continues the entry prev, elapsed is
+// the time since the last line was received, 0 when it doesn't matter
+func (ml *Multiline) Continues(prev *LogEntry, line string, meta map[string]string, elapsed time.Duration) bool {
+	if ml == nil || prev == nil || prev.Marker || elapsed > ml.Timeout {
+		return false
+	}
+	// the lines of different containers or files are never merged
+	if !maps.Equal(prev.Meta, meta) {
+		return false
+	}
+	if strings.Count(prev.Raw, "\n")+1 >= ml.MaxLines {
+		return false
+	}
+	if ml.Indent && line != "" && (line[0] == ' ' || line[0] == '\t') {
+		return true
+	}
+	for _, re := range ml.Continuation {
+		if re.MatchString(line) {
+			return true
+		}
+	}
+	if line == "" && ml.traceOpen(prev.Raw) {
+		for _, re := range ml.Blank {
+			if re.MatchString(prev.Raw) {
+				return true
+			}
+		}
+	}
+	if len(ml.Start) == 0 {
+		return false
+	}
+	for _, re := range ml.Start {
+		if re.MatchString(line) {
+			return false
+		}
+	}
+	return true
+}
+
+// traceOpen reports whether the last line of the entry is part of a trace,
+// indented, a frame or its first line, so a blank line may continue it
+func (ml *Multiline) traceOpen(raw string) bool {
+	last := raw[strings.LastIndexByte(raw, '\n')+1:]
+	if last == "" {
+		return false
+	}
+	if last[0] == ' ' || last[0] == '\t' {
+		return true
+	}
+	for _, re := range ml.Continuation {
+		if re.MatchString(last) {
+			return true
+		}
+	}
+	for _, re := range ml.Blank {
+		if re.MatchString(last) {
+			return true
+		}
+	}
+	return false
+}
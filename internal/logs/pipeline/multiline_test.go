@@ -0,0 +1,106 @@
+package pipeline
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+)
+
+// group merges the lines the way the logs view does
+func group(t *testing.T, preset string, text string) []string {
+	t.Helper()
+	return groupConfig(t, &config.Multiline{Presets: []string{preset}}, text)
+}
+
+func groupConfig(t *testing.T, cfg *config.Multiline, text string) []string {
+	t.Helper()
+	ml, err := compileMultiline(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var entries []*LogEntry
+	for _, line := range strings.Split(text, "\n") {
+		if n := len(entries); n > 0 && ml.Continues(entries[n-1], line, nil, 0) {
+			entries[n-1].Raw += "\n" + line
+			continue
+		}
+		entries = append(entries, &LogEntry{Raw: line})
+	}
+	raws := make([]string, len(entries))
+	for i, l := range entries {
+		raws[i] = l.Raw
+	}
+	return raws
+}
+
+func TestMultilineBlankLines(t *testing.T) {
+	tests := []struct {
+		preset string
+		text   string
+		want   int
+	}{
+		{"go", `starting
+panic: runtime error: index out of range [0] with length 0
+
+goroutine 1 [running]:
+main.main()
+	/app/main.go:10 +0x1d
+
+goroutine 6 [chan receive]:
+main.worker()
+	/app/main.go:20 +0x25
+exit status 2
+done`, 3},
+		{"python", `Traceback (most recent call last):
+  File "app.py", line 3, in <module>
+    int("x")
+ValueError: invalid literal for int() with base 10: 'x'
+
+During handling of the above exception, another exception occurred:
+
+Traceback (most recent call last):
+  File "app.py", line 5, in <module>
+    raise RuntimeError("failed")
+RuntimeError: failed
+done`, 2},
+		// blank lines don't continue the entries without a trace
+		{"go", "one\n\ntwo", 3},
+		// a blank line only continues the trace after one of its lines,
+		// the blank lines after it are entries
+		{"go", "panic: boom\n\ngoroutine 1 [running]:\nmain.main()\n\t/app/main.go:10 +0x1d\nexit status 2\n\n\n\nnext", 4},
+		{"python", "Traceback (most recent call last):\n  File \"app.py\", line 3\nValueError: x\nretrying\n\nok", 4},
+	}
+	for _, tt := range tests {
+		if got := group(t, tt.preset, tt.text); len(got) != tt.want {
+			t.Errorf("%s: got %d entries, want %d: %q", tt.preset, len(got), tt.want, got)
+		}
+	}
+}
+
+func TestMultilineMaxLines(t *testing.T) {
+	lines := make([]string, 12)
+	for i := range lines {
+		lines[i] = "a line that doesn't match the start pattern"
+	}
+	text := "2024-01-02 started\n" + strings.Join(lines, "\n")
+
+	got := groupConfig(t, &config.Multiline{Start: []string{`^\d{4}-`}, MaxLines: 5}, text)
+	if len(got) != 3 {
+		t.Fatalf("got %d entries, want 3: %q", len(got), got)
+	}
+	for _, raw := range got {
+		if n := strings.Count(raw, "\n") + 1; n > 5 {
+			t.Errorf("an entry has %d lines, want at most 5", n)
+		}
+	}
+
+	got = groupConfig(t, &config.Multiline{Start: []string{`^\d{4}-`}}, text)
+	if len(got) != 1 {
+		t.Errorf("got %d entries under the default limit, want 1", len(got))
+	}
+
+	if _, err := compileMultiline(&config.Multiline{Indent: true, MaxLines: -1}); err == nil {
+		t.Error("a negative maxLines compiled")
+	}
+}
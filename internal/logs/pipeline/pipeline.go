@@ -2,7 +2,9 @@ package pipeline
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 	"unicode"
@@ -25,9 +27,74 @@ type LogEntry struct {
 	Raw       string
 	Formatted string
 	Json      map[string]interface{}
+	Meta      map[string]string
+	Marker    bool
 	Height    int
 	CumHeight int
 	Index     int
+	// TransformErr and FilterErr are the errors evaluating the
+	// expressions of the view for this entry
+	TransformErr error
+	FilterErr    error
+}
+
+// Err returns the first error evaluating the expressions of the view
+func (l *LogEntry) Err() error {
+	if l.TransformErr != nil {
+		return l.TransformErr
+	}
+	return l.FilterErr
+}
+
+// Field returns the value of a dotted field of the json of the entry,
+// or of its source metadata when the field is meta or meta.<key>
+func (l *LogEntry) Field(field string) (interface{}, bool) {
+	if isMetaField(l, field) {
+		key := strings.TrimPrefix(strings.TrimPrefix(field, "meta"), ".")
+		if key == "" {
+			return l.Meta, true
+		}
+		value, ok := l.Meta[key]
+		return value, ok
+	}
+	return lookup(l.Json, strings.Split(field, "."))
+}
+
+// FieldText returns a value of the json as text, strings are
+// returned as they are and objects and arrays as json
+func FieldText(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return v
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format(time.RFC3339)
+	case map[string]interface{}, []interface{}, map[string]string:
+		b, _ := json.Marshal(v)
+		return string(b)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// lookup returns the value of a dotted field of the json
+func lookup(j map[string]interface{}, parts []string) (interface{}, bool) {
+	for i, part := range parts {
+		value, ok := j[part]
+		if !ok {
+			return nil, false
+		}
+		if i == len(parts)-1 {
+			return value, true
+		}
+		if j, ok = value.(map[string]interface{}); !ok {
+			return nil, false
+		}
+	}
+	return nil, false
 }
 
 func numberToGoTypes(j interface{}) interface{} {
@@ -51,24 +118,143 @@ func numberToGoTypes(j interface{}) interface{} {
 	return j
 }
 
-func runToJson(l *LogEntry) error {
+// Parsers of the lines, ParserAuto decodes JSON objects, the lines
+// matching a pattern of the view and the lines where every field
+// is a logfmt key=value pair. ParserRegex only uses the patterns
+const (
+	ParserAuto   = "auto"
+	ParserJSON   = "json"
+	ParserLogfmt = "logfmt"
+	ParserRegex  = "regex"
+	ParserNone   = "none"
+)
+
+type LogParser struct {
+	Parser   string
+	Patterns []*Pattern
+	// DecodeNested decodes the JSON held in string fields
+	DecodeNested bool
+}
+
+func (lp *LogParser) Validate() error {
+	switch lp.Parser {
+	case "", ParserAuto, ParserJSON, ParserLogfmt, ParserNone:
+		return nil
+	case ParserRegex:
+		if len(lp.Patterns) == 0 {
+			return errors.New("the regex parser needs the patterns of the view")
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown parser %q, use auto, json, logfmt, regex or none", lp.Parser)
+}
+
+// RunToJson fills the Json of the entry with the fields of the line,
+// it is left nil if the line can't be parsed. The CRI, Docker json-file
+// and timestamp prefixes are removed and their fields added to the line
+func (lp *LogParser) RunToJson(l *LogEntry) error {
 	l.Json = nil
-	if l.Raw[0] == '{' {
-		d := jsonLib.NewDecoder(strings.NewReader(l.Raw))
-		d.UseNumber()
-		if err := d.Decode(&l.Json); err != nil {
-			l.Json = nil
-			return nil
+	if l.Marker || l.Raw == "" {
+		return nil
+	}
+	if lp.Parser == ParserRegex || lp.Parser == ParserNone {
+		l.Json = lp.parse(l.Raw)
+	} else {
+		l.Json = lp.parsePrefixed(l.Raw)
+	}
+	if lp.DecodeNested && l.Json != nil {
+		decodeNested(l.Json, 0)
+	}
+	return nil
+}
+
+// parsePrefixed parses the line without its CRI or timestamp prefix, or the
+// line of a Docker json-file entry, and adds the fields of the prefix. The
+// whole line is parsed when it has no prefix or the rest has no fields
+func (lp *LogParser) parsePrefixed(raw string) map[string]interface{} {
+	line, attrs := stripPrefix(raw)
+	var j map[string]interface{}
+	if attrs == nil && raw[0] == '{' {
+		j = parseJSON(raw)
+		line, attrs, _ = jsonFileLine(j)
+	}
+	if attrs == nil {
+		// the json parsers would parse the line again
+		if j != nil && lp.Parser != ParserLogfmt {
+			return j
+		}
+		return lp.parse(raw)
+	}
+	// an empty CRI or json-file line has no fields
+	if line == "" {
+		return nil
+	}
+	if fields := lp.parse(line); fields != nil {
+		addAttrs(fields, attrs)
+		return fields
+	}
+	if j != nil {
+		return j
+	}
+	return lp.parse(raw)
+}
+
+func (lp *LogParser) parse(line string) map[string]interface{} {
+	if line == "" {
+		return nil
+	}
+	switch lp.Parser {
+	case ParserJSON:
+		return parseJSON(line)
+	case ParserLogfmt:
+		return parseLogfmt(line, false)
+	case ParserRegex:
+		return lp.parsePatterns(line)
+	case ParserNone:
+		return nil
+	}
+	if line[0] == '{' {
+		return parseJSON(line)
+	}
+	if j := lp.parsePatterns(line); j != nil {
+		return j
+	}
+	return parseLogfmt(line, true)
+}
+
+// parsePatterns returns the fields of the first pattern matching the line
+func (lp *LogParser) parsePatterns(line string) map[string]interface{} {
+	for _, p := range lp.Patterns {
+		if j := p.parse(line); j != nil {
+			return j
 		}
-		numberToGoTypes(l.Json)
 	}
 	return nil
 }
 
+func parseJSON(line string) map[string]interface{} {
+	var j map[string]interface{}
+	d := jsonLib.NewDecoder(strings.NewReader(line))
+	d.UseNumber()
+	if err := d.Decode(&j); err != nil {
+		return nil
+	}
+	numberToGoTypes(j)
+	return j
+}
+
+// markerStyle is the style of the marker lines added by the sources
+const markerStyle = "\033[3m\033[38;5;141m"
+
 type LogFormat struct {
 	ReturnedFields []string
 	Width          uint
 	Highlight      bool
+	Display        Display
+	// columnWidths are the widths of the columns of the table, they
+	// grow to fit the entries. columnsChanged is set when they grow
+	columnWidths   []int
+	columnsChanged bool
 }
 
 func (lt *LogFormat) RunReturnedFieldsAndFormat(l *LogEntry) error {
@@ -77,8 +263,26 @@ func (lt *LogFormat) RunReturnedFieldsAndFormat(l *LogEntry) error {
 		l.Formatted = ""
 		return nil
 	}
+	if l.Marker {
+		l.Formatted = markerStyle + "── " + l.Raw + " ──" + json_format.ResetStyle
+		if lt.Width != 0 {
+			l.Formatted = WrapString(l.Formatted, int(lt.Width))
+		}
+		l.Height = len(strings.Split(l.Formatted, "\n"))
+		return nil
+	}
+	columns := lt.columns()
 	if len(l.Json) == 0 {
 		l.Formatted = l.Raw
+		if lt.Display == DisplayTable && len(columns) > 0 {
+			// the text lines take a single row too
+			l.Formatted = lineBreaks.Replace(l.Raw)
+			if lt.Width != 0 {
+				l.Formatted = runewidth.Truncate(l.Formatted, int(lt.Width), ellipsis)
+			}
+			l.Height = 1
+			return nil
+		}
 		if lt.Width != 0 {
 			l.Formatted = wordwrap.WrapString(l.Formatted, lt.Width)
 		}
@@ -86,6 +290,11 @@ func (lt *LogFormat) RunReturnedFieldsAndFormat(l *LogEntry) error {
 		return nil
 	}
 
+	if lt.Display == DisplayTable && len(columns) > 0 {
+		lt.formatTable(l, columns)
+		return nil
+	}
+
 	j := l.Json
 
 	if len(lt.ReturnedFields) != 0 {
@@ -119,6 +328,10 @@ func (lt *LogFormat) RunReturnedFieldsAndFormat(l *LogEntry) error {
 					}
 				}
 
+			case isMetaField(l, field):
+				// Source metadata
+				addMetaToResult(l.Meta, strings.TrimPrefix(strings.TrimPrefix(field, "meta"), "."), j)
+
 			default:
 				// No wildcards
 				fieldParts := strings.Split(field, ".")
@@ -127,13 +340,17 @@ func (lt *LogFormat) RunReturnedFieldsAndFormat(l *LogEntry) error {
 		}
 	}
 
-	if lt.Highlight {
-		style := &json_format.LightStyle
-		if config.Theme == "dark" {
-			style = &json_format.DarkStyle
-		}
-		l.Formatted = json_format.PrettyPrintJSON(j, 2, style)
-	} else {
+	// a table without columns shows the entries on a single line too
+	compact := lt.Display == DisplayCompact || lt.Display == DisplayTable
+	switch {
+	case compact && lt.Highlight:
+		l.Formatted = json_format.CompactJSON(j, lt.style())
+	case compact:
+		jsonLog, _ := json.Marshal(j)
+		l.Formatted = string(jsonLog)
+	case lt.Highlight:
+		l.Formatted = json_format.PrettyPrintJSON(j, 2, lt.style())
+	default:
 		jsonLog, _ := json.MarshalIndent(j, "", "  ")
 		l.Formatted = string(jsonLog)
 	}
@@ -146,6 +363,13 @@ func (lt *LogFormat) RunReturnedFieldsAndFormat(l *LogEntry) error {
 	return nil
 }
 
+func (lt *LogFormat) style() *json_format.Style {
+	if config.Theme == "dark" {
+		return &json_format.DarkStyle
+	}
+	return &json_format.LightStyle
+}
+
 // addToResult recursively navigates through the Json map and adds the specified field to the result.
 func addToResult(currentMap map[string]interface{}, fieldParts []string, index int, result map[string]interface{}) {
 	if index == len(fieldParts)-1 {
@@ -166,6 +390,40 @@ func addToResult(currentMap map[string]interface{}, fieldParts []string, index i
 	}
 }
 
+// isMetaField reports whether the field refers to the source metadata, "meta"
+// or "meta.<key>". A json field called meta takes precedence over the metadata
+// unless the key exists in the metadata.
+func isMetaField(l *LogEntry, field string) bool {
+	if len(l.Meta) == 0 {
+		return false
+	}
+	if field == "meta" {
+		_, inJson := l.Json["meta"]
+		return !inJson
+	}
+	key, ok := strings.CutPrefix(field, "meta.")
+	if !ok {
+		return false
+	}
+	_, inMeta := l.Meta[key]
+	return inMeta
+}
+
+// addMetaToResult adds the source metadata key to the result under "meta",
+// an empty key adds all the metadata.
+func addMetaToResult(meta map[string]string, key string, result map[string]interface{}) {
+	m, ok := result["meta"].(map[string]interface{})
+	if !ok {
+		m = make(map[string]interface{})
+		result["meta"] = m
+	}
+	for k, v := range meta {
+		if key == "" || k == key {
+			m[k] = v
+		}
+	}
+}
+
 // insertIntoMap inserts the value into the result map, maintaining the structure defined by fieldParts.
 func insertIntoMap(result map[string]interface{}, fieldParts []string, value interface{}) {
 	for i := len(fieldParts) - 1; i >= 0; i-- {
@@ -187,13 +445,13 @@ func level2Int(level string) int {
 	switch strings.ToLower(level) {
 	case "debug":
 		return 0
-	case "info":
+	case "info", "i":
 		return 1
-	case "warn", "warning":
+	case "warn", "warning", "w":
 		return 2
-	case "error":
+	case "error", "e":
 		return 3
-	case "fatal", "panic":
+	case "fatal", "panic", "f":
 		return 4
 	}
 	return 0
@@ -244,16 +502,19 @@ func (lf *LogFilter) Compile() error {
 }
 
 func (lf *LogFilter) RunFilter(l *LogEntry) error {
-	if lf.Filter == nil {
+	l.FilterErr = nil
+	if lf.Filter == nil || l.Marker {
 		l.Show = true
 		return nil
 	}
 	r, err := vm.Run(lf.Filter, map[string]interface{}{
 		"text": l.Raw,
 		"json": l.Json,
+		"meta": l.Meta,
 	})
 	if err != nil {
 		l.Show = lf.Default
+		l.FilterErr = err
 		return err
 	} else if r == nil || r.(bool) {
 		l.Show = true
@@ -267,36 +528,47 @@ type LogTransform struct {
 	Transforms []func(*LogEntry) error
 }
 
-func compileLogTransforms(transforms []config.Transform) []func(*LogEntry) error {
+// compileLogTransforms compiles the transforms, the ones
+// that don't compile are skipped and their errors returned
+func compileLogTransforms(transforms []config.Transform) ([]func(*LogEntry) error, error) {
 	tf := make([]func(*LogEntry) error, 0, len(transforms))
+	var errs []error
 	for _, t := range transforms {
 		p, err := expr.Compile(t.Expression, toDateStr, toLocalDateStr)
 		if err != nil {
-			fmt.Printf("error compiling expression: %v\n", err)
+			errs = append(errs, fmt.Errorf("transform %s: %w", t.Field, err))
 			continue
 		}
 		f := func(l *LogEntry) error {
+			// transforms set json fields, so they only apply to json logs
+			if l.Json == nil {
+				return nil
+			}
 			r, err := vm.Run(p, map[string]interface{}{
 				"text": l.Raw,
 				"json": l.Json,
+				"meta": l.Meta,
 			})
 			if err != nil {
-				fmt.Printf("error running expression: %v\n", err)
-				return err
+				return fmt.Errorf("transform %s: %w", t.Field, err)
 			}
 			l.Json[t.Field] = r
 			return nil
 		}
 		tf = append(tf, f)
 	}
-	return tf
+	return tf, errors.Join(errs...)
 }
 
+// RunTransform runs every transform, keeping the first error
 func (lt *LogTransform) RunTransform(l *LogEntry) error {
+	l.TransformErr = nil
 	for _, f := range lt.Transforms {
-		_ = f(l)
+		if err := f(l); err != nil && l.TransformErr == nil {
+			l.TransformErr = err
+		}
 	}
-	return nil
+	return l.TransformErr
 }
 
 type LogPipeline struct {
@@ -306,7 +578,17 @@ type LogPipeline struct {
 	lf        *LogFilter
 	lft       *LogFormat
 	lt        *LogTransform
+	lps       *LogParser
 	Cfg       *config.View
+	// Multiline merges the lines of the entries spanning many lines
+	// before they go through the pipeline, nil when they aren't merged
+	Multiline *Multiline
+	// the errors compiling the view
+	multilineErr  error
+	parserErr     error
+	transformsErr error
+	filterErr     error
+	displayErr    error
 }
 
 func New(cfg *config.View, width uint) (*LogPipeline, error) {
@@ -320,21 +602,44 @@ func New(cfg *config.View, width uint) (*LogPipeline, error) {
 	if err := lf.Compile(); err != nil {
 		return nil, err
 	}
+	display, err := ParseDisplay(cfg.Display)
+	if err != nil {
+		return nil, err
+	}
 	lft := &LogFormat{
 		ReturnedFields: cfg.ReturnedFields,
 		Width:          width,
 		Highlight:      true,
+		Display:        display,
+	}
+	transforms, err := compileLogTransforms(cfg.Transforms)
+	if err != nil {
+		return nil, err
+	}
+	lt := &LogTransform{Transforms: transforms}
+	patterns, err := compilePatterns(cfg.Patterns)
+	if err != nil {
+		return nil, err
+	}
+	lps := &LogParser{Parser: cfg.Parser, Patterns: patterns, DecodeNested: cfg.DecodeNested}
+	if err := lps.Validate(); err != nil {
+		return nil, err
+	}
+	multiline, err := compileMultiline(cfg.Multiline)
+	if err != nil {
+		return nil, err
 	}
-	lt := &LogTransform{Transforms: compileLogTransforms(cfg.Transforms)}
 	lp := &LogPipeline{
-		lf:  lf,
-		lft: lft,
-		lt:  lt,
-		Cfg: cfg,
+		lf:        lf,
+		lft:       lft,
+		lt:        lt,
+		lps:       lps,
+		Cfg:       cfg,
+		Multiline: multiline,
 	}
 	lp.Pipeline = []func(*LogEntry) error{
 		lp.setIndex,
-		runToJson,
+		lps.RunToJson,
 		lt.RunTransform,
 		lf.RunFilter,
 		lft.RunReturnedFieldsAndFormat,
@@ -383,14 +688,26 @@ func (lp *LogPipeline) RunWidthChanged(l *LogEntry) error {
 	return nil
 }
 
+// SetFilter compiles the filter, every entry is shown when it doesn't compile
 func (lp *LogPipeline) SetFilter(filter string) error {
 	lp.Reset()
 	lp.lf.FilterExpr = filter
-	if filter == "" {
-		lp.lf.Filter = nil
-		return nil
+	lp.filterErr = lp.lf.Compile()
+	return lp.filterErr
+}
+
+// FilterErr returns the error compiling the filter
+func (lp *LogPipeline) FilterErr() error {
+	return lp.filterErr
+}
+
+// CompileErr returns the errors compiling the filter and the transforms
+func (lp *LogPipeline) CompileErr() error {
+	var filterErr error
+	if lp.filterErr != nil {
+		filterErr = fmt.Errorf("filter: %w", lp.filterErr)
 	}
-	return lp.lf.Compile()
+	return errors.Join(lp.multilineErr, lp.parserErr, lp.displayErr, lp.transformsErr, filterErr)
 }
 
 func (lp *LogPipeline) RunFilterChanged(l *LogEntry) error {
@@ -410,9 +727,36 @@ func (lp *LogPipeline) SetReturnedFields(fields []string) error {
 		}
 	}
 	lp.lft.ReturnedFields = fields
+	lp.lft.columnWidths = nil
 	return nil
 }
 
+// SetDisplay sets how the entries are formatted
+func (lp *LogPipeline) SetDisplay(display Display) {
+	lp.Reset()
+	lp.lft.Display = display
+	lp.lft.columnWidths = nil
+}
+
+// Display returns how the entries are formatted
+func (lp *LogPipeline) Display() Display {
+	return lp.lft.Display
+}
+
+// Header returns the header row of the table, empty
+// when the entries aren't shown as a table
+func (lp *LogPipeline) Header() string {
+	return lp.lft.Header()
+}
+
+// ColumnsChanged reports whether the columns of the table grew since it
+// was last called, the entries must be formatted again to be aligned
+func (lp *LogPipeline) ColumnsChanged() bool {
+	changed := lp.lft.columnsChanged
+	lp.lft.columnsChanged = false
+	return changed
+}
+
 func (lp *LogPipeline) RunReturnedFieldsChanged(l *LogEntry) error {
 	for _, f := range lp.Pipeline[4:] {
 		_ = f(l)
@@ -420,24 +764,67 @@ func (lp *LogPipeline) RunReturnedFieldsChanged(l *LogEntry) error {
 	return nil
 }
 
+// SetMultiline compiles the multiline config, the entries already in the
+// buffer must be merged again with it
+func (lp *LogPipeline) SetMultiline(cfg *config.Multiline) error {
+	lp.Multiline, lp.multilineErr = compileMultiline(cfg)
+	return lp.multilineErr
+}
+
+// SetParser sets the parser of the lines and compiles the patterns,
+// auto is used when the parser is unknown
+func (lp *LogPipeline) SetParser(parser string, patterns []config.Pattern) error {
+	lp.Reset()
+	var errs []error
+	lp.lps.Patterns, lp.parserErr = compilePatterns(patterns)
+	if lp.parserErr != nil {
+		errs = append(errs, lp.parserErr)
+	}
+	lp.lps.Parser = parser
+	if err := lp.lps.Validate(); err != nil {
+		lp.lps.Parser = ParserAuto
+		errs = append(errs, fmt.Errorf("parser: %w", err))
+	}
+	lp.parserErr = errors.Join(errs...)
+	return lp.parserErr
+}
+
+// SetTransforms compiles the transforms, the ones that
+// don't compile are skipped and their errors returned
 func (lp *LogPipeline) SetTransforms(transforms []config.Transform) error {
 	lp.Reset()
-	lp.lt.Transforms = compileLogTransforms(transforms)
-	return nil
+	lp.lt.Transforms, lp.transformsErr = compileLogTransforms(transforms)
+	return lp.transformsErr
 }
 
+// SetView sets every part of the view, even when some of them don't
+// compile, and returns the errors of all of them
 func (lp *LogPipeline) SetView(view *config.View) error {
 	lp.Reset()
 	lp.Cfg = view
 	lp.lf.Default = view.FilterDefault
-	if err := lp.SetTransforms(view.Transforms); err != nil {
-		return err
-	}
-	if err := lp.SetFilter(view.Filter); err != nil {
-		return err
+	lp.lps.DecodeNested = view.DecodeNested
+	var display Display
+	display, lp.displayErr = ParseDisplay(view.Display)
+	lp.SetDisplay(display)
+	return errors.Join(
+		lp.displayErr,
+		lp.SetMultiline(view.Multiline),
+		lp.SetParser(view.Parser, view.Patterns),
+		lp.SetTransforms(view.Transforms),
+		lp.SetFilter(view.Filter),
+		lp.SetReturnedFields(view.ReturnedFields),
+	)
+}
+
+// RunLastChanged runs the pipeline again over the last entry,
+// after more lines were added to it
+func (lp *LogPipeline) RunLastChanged(l *LogEntry) error {
+	if l.Show {
+		lp.cumHeight -= l.Height
 	}
-	if err := lp.SetReturnedFields(view.ReturnedFields); err != nil {
-		return err
+	for _, f := range lp.Pipeline[1:] {
+		_ = f(l)
 	}
 	return nil
 }
@@ -0,0 +1,88 @@
+package pipeline
+
+import (
+	"regexp"
+	"strings"
+)
+
+// maxNestedDepth limits how deep JSON encoded in strings is decoded
+const maxNestedDepth = 8
+
+var (
+	// criPrefix is the prefix of the lines written by containerd and
+	// CRI-O, like 2024-01-02T10:00:00.000000000Z stdout F
+	criPrefix = regexp.MustCompile(`^(\d{4}-\d{2}-\d{2}T\S+) (stdout|stderr) ([FP]) `)
+	// timestampPrefix is a timestamp before the line, like the ones
+	// added by docker logs -t and kubectl logs --timestamps
+	timestampPrefix = regexp.MustCompile(`^\[?(\d{4}-\d{2}-\d{2}[T ]\d{2}:\d{2}:\d{2}(?:[.,]\d+)?(?:Z|[+-]\d{2}:?\d{2})?)\]? `)
+)
+
+// stripPrefix removes the CRI or timestamp prefix of the line,
+// the fields of the prefix are returned, nil if there is none
+func stripPrefix(line string) (string, map[string]interface{}) {
+	if m := criPrefix.FindStringSubmatch(line); m != nil {
+		return line[len(m[0]):], map[string]interface{}{"time": m[1], "stream": m[2], "logtag": m[3]}
+	}
+	if m := timestampPrefix.FindStringSubmatch(line); m != nil {
+		return line[len(m[0]):], map[string]interface{}{"time": m[1]}
+	}
+	return line, nil
+}
+
+// jsonFileLine returns the line and the fields of an entry of the Docker
+// json-file logging driver, {"log":"...\n","stream":"stdout","time":"..."}
+func jsonFileLine(j map[string]interface{}) (string, map[string]interface{}, bool) {
+	log, ok := j["log"].(string)
+	if !ok {
+		return "", nil, false
+	}
+	attrs := make(map[string]interface{})
+	for k, v := range j {
+		switch k {
+		case "log":
+		case "stream", "time", "attrs":
+			attrs[k] = v
+		default:
+			return "", nil, false
+		}
+	}
+	return strings.TrimSuffix(log, "\n"), attrs, true
+}
+
+// addAttrs adds the fields of a prefix to the fields of the line,
+// the fields of the line are kept when both have them
+func addAttrs(j map[string]interface{}, attrs map[string]interface{}) {
+	for k, v := range attrs {
+		if _, ok := j[k]; !ok {
+			j[k] = v
+		}
+	}
+}
+
+// decodeNested replaces the strings holding JSON objects
+// or arrays with their decoded value
+func decodeNested(v interface{}, depth int) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, value := range v {
+			v[k] = decodeNested(value, depth)
+		}
+	case []interface{}:
+		for i, value := range v {
+			v[i] = decodeNested(value, depth)
+		}
+	case string:
+		s := strings.TrimSpace(v)
+		if depth >= maxNestedDepth || s == "" || (s[0] != '{' && s[0] != '[') {
+			return v
+		}
+		var nested interface{}
+		d := jsonLib.NewDecoder(strings.NewReader(s))
+		d.UseNumber()
+		if err := d.Decode(&nested); err != nil || d.More() {
+			return v
+		}
+		return decodeNested(numberToGoTypes(nested), depth+1)
+	}
+	return v
+}
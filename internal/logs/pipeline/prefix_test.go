@@ -0,0 +1,59 @@
+package pipeline
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRunToJsonPrefixedLines(t *testing.T) {
+	const ts = "2024-01-02T10:00:00.000Z"
+	cri := map[string]interface{}{"time": ts, "stream": "stdout", "logtag": "F"}
+	jsonFile := map[string]interface{}{"time": ts, "stream": "stdout"}
+	with := func(attrs map[string]interface{}, fields map[string]interface{}) map[string]interface{} {
+		j := make(map[string]interface{})
+		for k, v := range attrs {
+			j[k] = v
+		}
+		for k, v := range fields {
+			j[k] = v
+		}
+		return j
+	}
+
+	tests := []struct {
+		name, line string
+		parsers    []string
+		want       map[string]interface{}
+	}{
+		// an empty line has no fields, not even the ones of its prefix
+		{"blank CRI", ts + " stdout F ", []string{ParserAuto, ParserJSON, ParserLogfmt}, nil},
+		{"blank json-file", `{"log":"\n","stream":"stdout","time":"` + ts + `"}`, []string{ParserAuto, ParserJSON, ParserLogfmt}, nil},
+		{"CRI json", ts + ` stdout F {"msg":"hi"}`, []string{ParserAuto, ParserJSON},
+			with(cri, map[string]interface{}{"msg": "hi"})},
+		{"CRI logfmt", ts + " stdout F msg=hi level=info", []string{ParserAuto, ParserLogfmt},
+			with(cri, map[string]interface{}{"msg": "hi", "level": "info"})},
+		{"json-file json", `{"log":"{\"msg\":\"hi\",\"stream\":\"app\"}\n","stream":"stdout","time":"` + ts + `"}`, []string{ParserAuto, ParserJSON},
+			with(jsonFile, map[string]interface{}{"msg": "hi", "stream": "app"})},
+		{"json-file logfmt", `{"log":"msg=hi level=info\n","stream":"stdout","time":"` + ts + `"}`, []string{ParserAuto, ParserLogfmt},
+			with(jsonFile, map[string]interface{}{"msg": "hi", "level": "info"})},
+		{"timestamp logfmt", "2024-01-02T10:00:00Z msg=hi", []string{ParserAuto, ParserLogfmt},
+			map[string]interface{}{"time": "2024-01-02T10:00:00Z", "msg": "hi"}},
+		// the prefix is kept when the rest of the line has no fields
+		{"CRI text", ts + " stdout F plain text", []string{ParserAuto, ParserJSON}, nil},
+		{"json-file text", `{"log":"plain text\n","stream":"stdout","time":"` + ts + `"}`, []string{ParserJSON},
+			map[string]interface{}{"log": "plain text\n", "stream": "stdout", "time": ts}},
+	}
+	for _, tt := range tests {
+		for _, parser := range tt.parsers {
+			lp := &LogParser{Parser: parser}
+			l := &LogEntry{Raw: tt.line}
+			if err := lp.RunToJson(l); err != nil {
+				t.Errorf("%s, parser %s: %v", tt.name, parser, err)
+				continue
+			}
+			if !reflect.DeepEqual(l.Json, tt.want) {
+				t.Errorf("%s, parser %s: got %v, want %v", tt.name, parser, l.Json, tt.want)
+			}
+		}
+	}
+}
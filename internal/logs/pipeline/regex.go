@@ -0,0 +1,158 @@
+package pipeline
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+)
+
+// presets are the patterns of common log formats, used
+// with `preset = "<name>"` in the patterns of a view
+var presets = map[string][]config.Pattern{
+	// Apache and nginx combined and common log formats
+	"combined": {{
+		Regex: `^(?P<remote_addr>\S+) (?P<ident>\S+) (?P<remote_user>\S+) \[(?P<time>[^\]]+)\] ` +
+			`"(?P<method>[A-Z]+) (?P<path>\S+) (?P<protocol>[^"]+)" (?P<status>\d{3}) (?:(?P<bytes>\d+)|-)` +
+			`(?: "(?P<referer>[^"]*)" "(?P<user_agent>[^"]*)"?)?`,
+		Types:      map[string]string{"time": "time", "status": "int", "bytes": "int"},
+		TimeLayout: "02/Jan/2006:15:04:05 -0700",
+	}},
+	"syslog": {{
+		// RFC 5424
+		Regex: `^<(?P<priority>\d{1,3})>1 (?P<time>\S+) (?P<host>\S+) (?P<app>\S+) (?P<pid>\S+) (?P<msgid>\S+) ` +
+			`(?P<structured_data>-|\[.*?\]) ?(?P<msg>(?s:.*))$`,
+		Types: map[string]string{"time": "time", "priority": "int"},
+	}, {
+		// RFC 3164, the BSD format
+		Regex: `^(?:<(?P<priority>\d{1,3})>)?(?P<time>[A-Z][a-z]{2} [ \d]\d \d{2}:\d{2}:\d{2}) (?P<host>\S+) ` +
+			`(?P<app>[^\s\[:]+)(?:\[(?P<pid>\d+)\])?: (?P<msg>(?s:.*))$`,
+		Types:      map[string]string{"time": "time", "priority": "int", "pid": "int"},
+		TimeLayout: time.Stamp,
+	}},
+	// Kubernetes components, Lmmdd hh:mm:ss.uuuuuu threadid file:line] msg
+	"klog": {{
+		Regex: `^(?P<level>[IWEF])(?P<time>\d{4} \d{2}:\d{2}:\d{2}\.\d{6})\s+(?P<thread>\d+) ` +
+			`(?P<file>[^:\s]+):(?P<line>\d+)\] (?P<msg>(?s:.*))$`,
+		Types:      map[string]string{"time": "time", "thread": "int", "line": "int"},
+		TimeLayout: "0102 15:04:05.000000",
+	}},
+}
+
+func init() {
+	presets["apache"] = presets["combined"]
+	presets["nginx"] = presets["combined"]
+}
+
+// Pattern is a compiled config.Pattern
+type Pattern struct {
+	Regex      *regexp.Regexp
+	Types      map[string]string
+	TimeLayout string
+}
+
+// compilePatterns compiles the patterns of a view, expanding the presets.
+// The ones that don't compile are skipped and their errors returned
+func compilePatterns(patterns []config.Pattern) ([]*Pattern, error) {
+	var compiled []*Pattern
+	var errs []error
+	for i, p := range patterns {
+		expanded := []config.Pattern{p}
+		if p.Preset != "" {
+			var ok bool
+			if expanded, ok = presets[p.Preset]; !ok {
+				errs = append(errs, fmt.Errorf("pattern %d: unknown preset %q, use combined, apache, nginx, syslog or klog", i+1, p.Preset))
+				continue
+			}
+		}
+		for _, p := range expanded {
+			re, err := regexp.Compile(p.Regex)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("pattern %d: %w", i+1, err))
+				continue
+			}
+			if err := validateTypes(re, p.Types); err != nil {
+				errs = append(errs, fmt.Errorf("pattern %d: %w", i+1, err))
+				continue
+			}
+			compiled = append(compiled, &Pattern{Regex: re, Types: p.Types, TimeLayout: p.TimeLayout})
+		}
+	}
+	return compiled, errors.Join(errs...)
+}
+
+func validateTypes(re *regexp.Regexp, types map[string]string) error {
+	for field, t := range types {
+		if re.SubexpIndex(field) < 0 {
+			return fmt.Errorf("there is no group named %q", field)
+		}
+		switch t {
+		case "int", "float", "time":
+		default:
+			return fmt.Errorf("unknown type %q of %s, use int, float or time", t, field)
+		}
+	}
+	return nil
+}
+
+// parse returns the named groups of the line or nil if it doesn't match,
+// the groups that didn't match anything are left out
+func (p *Pattern) parse(line string) map[string]interface{} {
+	match := p.Regex.FindStringSubmatchIndex(line)
+	if match == nil {
+		return nil
+	}
+	j := make(map[string]interface{})
+	for i, name := range p.Regex.SubexpNames() {
+		if name == "" || match[2*i] < 0 {
+			continue
+		}
+		j[name] = p.coerce(name, line[match[2*i]:match[2*i+1]])
+	}
+	return j
+}
+
+// coerce converts the value of a field to its type,
+// it is kept as a string if it can't be converted
+func (p *Pattern) coerce(field string, value string) interface{} {
+	switch p.Types[field] {
+	case "int":
+		if i, err := strconv.ParseInt(value, 10, 64); err == nil {
+			return i
+		}
+	case "float":
+		if f, err := strconv.ParseFloat(value, 64); err == nil {
+			return f
+		}
+	case "time":
+		if t, ok := parseTime(value, p.TimeLayout); ok {
+			return t
+		}
+	}
+	return value
+}
+
+// parseTime parses the time with the layout, or as RFC 3339 when there
+// is no layout, in the local time zone if it has none. Layouts without
+// the year, like the ones of syslog and klog, get the current year
+func parseTime(value string, layout string) (time.Time, bool) {
+	if layout == "" {
+		layout = time.RFC3339Nano
+	}
+	t, err := time.ParseInLocation(layout, value, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if t.Year() == 0 {
+		now := time.Now()
+		t = t.AddDate(now.Year(), 0, 0)
+		// the logs of the last days of december read in january
+		if t.After(now.AddDate(0, 0, 1)) {
+			t = t.AddDate(-1, 0, 0)
+		}
+	}
+	return t, true
+}
@@ -0,0 +1,207 @@
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/filipecaixeta/logviewer/internal/logs/json_format"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// Display is how the entries are formatted
+type Display string
+
+const (
+	// DisplayExpanded pretty-prints the json of each entry
+	DisplayExpanded Display = "expanded"
+	// DisplayCompact prints the json of each entry on a single line
+	DisplayCompact Display = "compact"
+	// DisplayTable prints the returned fields of each entry as a row
+	DisplayTable Display = "table"
+)
+
+// Next returns the display after d, they are toggled in this order
+func (d Display) Next() Display {
+	switch d {
+	case DisplayCompact:
+		return DisplayTable
+	case DisplayTable:
+		return DisplayExpanded
+	default:
+		return DisplayCompact
+	}
+}
+
+// ParseDisplay returns the display with the given name,
+// expanded when the name is empty
+func ParseDisplay(name string) (Display, error) {
+	switch d := Display(name); d {
+	case "":
+		return DisplayExpanded, nil
+	case DisplayExpanded, DisplayCompact, DisplayTable:
+		return d, nil
+	}
+	return DisplayExpanded, fmt.Errorf("unknown display %q, use expanded, compact or table", name)
+}
+
+const (
+	columnSeparator = "  "
+	// maxColumnWidth is the widest a column gets, except the last one
+	// that takes the rest of the line
+	maxColumnWidth = 40
+	ellipsis       = "…"
+)
+
+const headerStyle = "\033[1m"
+
+// levelFields are the names of the columns colored by the level of the entry
+var levelFields = map[string]bool{
+	"level":    true,
+	"lvl":      true,
+	"severity": true,
+	"loglevel": true,
+}
+
+func levelStyle(level string) string {
+	switch strings.ToLower(level) {
+	case "trace", "debug", "d":
+		return "\033[38;5;245m"
+	case "info", "i":
+		return "\033[38;5;42m"
+	case "warn", "warning", "w":
+		return "\033[38;5;214m"
+	case "error", "e":
+		return "\033[38;5;197m"
+	case "fatal", "panic", "f":
+		return "\033[1m\033[38;5;197m"
+	}
+	return ""
+}
+
+// columns returns the returned fields shown as columns, the wildcard
+// fields are left out since they don't name a single value
+func (lt *LogFormat) columns() []string {
+	columns := make([]string, 0, len(lt.ReturnedFields))
+	for _, field := range lt.ReturnedFields {
+		if !strings.Contains(field, "*") {
+			columns = append(columns, field)
+		}
+	}
+	return columns
+}
+
+// formatTable formats the entry as a row of the table, the columns grow
+// to fit its values up to maxColumnWidth. The entries formatted before
+// are aligned again when ColumnsChanged reports it
+func (lt *LogFormat) formatTable(l *LogEntry, columns []string) {
+	cells := make([]string, len(columns))
+	for i, field := range columns {
+		cells[i] = cellValue(l, field)
+	}
+	lt.fit(columns, cells)
+
+	widths := lt.layout(columns)
+	var b strings.Builder
+	for i, value := range cells {
+		if i >= len(widths) {
+			break
+		}
+		if i > 0 {
+			b.WriteString(columnSeparator)
+		}
+		cell := runewidth.Truncate(value, widths[i], ellipsis)
+		if i < len(widths)-1 {
+			cell = runewidth.FillRight(cell, widths[i])
+		}
+		style := ""
+		if lt.Highlight && levelFields[strings.ToLower(lastPart(columns[i]))] {
+			style = levelStyle(value)
+		}
+		if style != "" {
+			cell = style + cell + json_format.ResetStyle
+		}
+		b.WriteString(cell)
+	}
+	l.Formatted = strings.TrimRight(b.String(), " ")
+	l.Height = 1
+}
+
+// fit grows the columns to fit the cells of an entry
+func (lt *LogFormat) fit(columns, cells []string) {
+	if len(lt.columnWidths) != len(columns) {
+		lt.columnWidths = make([]int, len(columns))
+		for i, column := range columns {
+			lt.columnWidths[i] = runewidth.StringWidth(column)
+		}
+		lt.columnsChanged = true
+	}
+	for i, cell := range cells {
+		if w := min(runewidth.StringWidth(cell), maxColumnWidth); w > lt.columnWidths[i] {
+			lt.columnWidths[i] = w
+			lt.columnsChanged = true
+		}
+	}
+}
+
+// layout returns the widths of the columns that fit in the width of the
+// screen, the last column takes the rest of the line
+func (lt *LogFormat) layout(columns []string) []int {
+	widths := make([]int, 0, len(columns))
+	used := 0
+	for i := range columns {
+		w := runewidth.StringWidth(columns[i])
+		if i < len(lt.columnWidths) {
+			w = lt.columnWidths[i]
+		}
+		if i > 0 {
+			used += len(columnSeparator)
+		}
+		if lt.Width != 0 {
+			rest := int(lt.Width) - used
+			if rest <= 0 {
+				break
+			}
+			if i == len(columns)-1 || w > rest {
+				w = rest
+			}
+		}
+		widths = append(widths, w)
+		used += w
+	}
+	return widths
+}
+
+// Header returns the header row of the table, empty when the entries
+// aren't shown as a table
+func (lt *LogFormat) Header() string {
+	columns := lt.columns()
+	if lt.Display != DisplayTable || len(columns) == 0 {
+		return ""
+	}
+	widths := lt.layout(columns)
+	names := make([]string, len(widths))
+	for i, w := range widths {
+		names[i] = runewidth.FillRight(runewidth.Truncate(columns[i], w, ellipsis), w)
+	}
+	header := strings.TrimRight(strings.Join(names, columnSeparator), " ")
+	if lt.Highlight {
+		header = headerStyle + header + json_format.ResetStyle
+	}
+	return header
+}
+
+// cellValue returns the value of a field of the entry as a single line
+func cellValue(l *LogEntry, field string) string {
+	value, ok := l.Field(field)
+	if !ok || value == nil {
+		return ""
+	}
+	return lineBreaks.Replace(FieldText(value))
+}
+
+var lineBreaks = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ", "\t", " ")
+
+func lastPart(field string) string {
+	return field[strings.LastIndex(field, ".")+1:]
+}
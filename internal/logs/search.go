@@ -0,0 +1,311 @@
+package logs
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/filipecaixeta/logviewer/internal/logs/pipeline"
+)
+
+const (
+	resetStyle         = "\033[0m"
+	searchMatchStyle   = "\033[30;48;5;228m"
+	searchCurrentStyle = "\033[30;48;5;208m"
+)
+
+// logSearch holds the state of an incremental search over the log entries
+type logSearch struct {
+	query    string
+	backward bool
+	// matches holds the Index of every visible LogEntry whose formatted
+	// text contains the query, in buffer order
+	matches []int
+	// current is the position in matches of the entry the view jumped to
+	current int
+}
+
+func (s *logSearch) active() bool {
+	return s.query != ""
+}
+
+func (s *logSearch) clear() {
+	s.query = ""
+	s.matches = s.matches[:0]
+	s.current = 0
+}
+
+// caseSensitive uses smart case, the search is only case sensitive
+// when the query contains an upper case character
+func (s *logSearch) caseSensitive() bool {
+	return strings.IndexFunc(s.query, unicode.IsUpper) != -1
+}
+
+func (s *logSearch) matchesEntry(l *pipeline.LogEntry) bool {
+	return l.Show && len(findMatches(l.Formatted, s.query, s.caseSensitive(), 1)) > 0
+}
+
+// refresh recomputes the matches, it must be called every time the
+// formatted text of the entries changes
+func (s *logSearch) refresh(c *circularLogBuffer) {
+	currentIndex := s.currentIndex()
+	s.matches = s.matches[:0]
+	if !s.active() {
+		return
+	}
+	c.RunPipeline(func(l *pipeline.LogEntry) error {
+		if s.matchesEntry(l) {
+			s.matches = append(s.matches, l.Index)
+		}
+		return nil
+	})
+	s.current = min(sort.SearchInts(s.matches, currentIndex), max(0, len(s.matches)-1))
+}
+
+// add checks a new entry added to the end of the buffer
+func (s *logSearch) add(l *pipeline.LogEntry) {
+	if s.active() && s.matchesEntry(l) {
+		s.matches = append(s.matches, l.Index)
+	}
+}
+
+// update checks again the last entry of the buffer after it changed
+func (s *logSearch) update(l *pipeline.LogEntry) {
+	if n := len(s.matches); n > 0 && s.matches[n-1] == l.Index {
+		s.matches = s.matches[:n-1]
+		s.current = min(s.current, max(0, len(s.matches)-1))
+	}
+	s.add(l)
+}
+
+// evict drops the matches of the entries that were removed from the buffer
+func (s *logSearch) evict(first *pipeline.LogEntry) {
+	if first == nil {
+		return
+	}
+	n := sort.SearchInts(s.matches, first.Index)
+	if n == 0 {
+		return
+	}
+	s.matches = append(s.matches[:0], s.matches[n:]...)
+	s.current = max(0, s.current-n)
+}
+
+// currentIndex returns the Index of the current match or -1 if there is none
+func (s *logSearch) currentIndex() int {
+	if s.current < 0 || s.current >= len(s.matches) {
+		return -1
+	}
+	return s.matches[s.current]
+}
+
+// seek selects the first match at or after index, or the last match at or
+// before index when searching backwards. It wraps around the buffer.
+func (s *logSearch) seek(index int) (int, bool) {
+	if len(s.matches) == 0 {
+		return 0, false
+	}
+	if s.backward {
+		s.current = sort.SearchInts(s.matches, index+1) - 1
+		if s.current < 0 {
+			s.current = len(s.matches) - 1
+		}
+	} else {
+		s.current = sort.SearchInts(s.matches, index)
+		if s.current == len(s.matches) {
+			s.current = 0
+		}
+	}
+	return s.matches[s.current], true
+}
+
+// step moves to the next match in the search direction, or to the previous
+// one when reverse is true
+func (s *logSearch) step(reverse bool) (int, bool) {
+	if len(s.matches) == 0 {
+		return 0, false
+	}
+	if s.backward != reverse {
+		s.current = (s.current - 1 + len(s.matches)) % len(s.matches)
+	} else {
+		s.current = (s.current + 1) % len(s.matches)
+	}
+	return s.matches[s.current], true
+}
+
+// count returns the position of the current match, like "match 3/41"
+func (s *logSearch) count() string {
+	if !s.active() {
+		return ""
+	}
+	if len(s.matches) == 0 {
+		return "no matches"
+	}
+	return fmt.Sprintf("match %d/%d", s.current+1, len(s.matches))
+}
+
+func (s *logSearch) status() string {
+	prompt := "/"
+	if s.backward {
+		prompt = "?"
+	}
+	return prompt + s.query + "  " + s.count()
+}
+
+// highlight returns the formatted text of the entry with every match highlighted
+func (s *logSearch) highlight(l *pipeline.LogEntry) string {
+	if !s.active() {
+		return l.Formatted
+	}
+	style := searchMatchStyle
+	if l.Index == s.currentIndex() {
+		style = searchCurrentStyle
+	}
+	return highlightMatches(l.Formatted, findMatches(l.Formatted, s.query, s.caseSensitive(), -1), style)
+}
+
+// visibleRune is a rune of a string that is printed on the screen,
+// pos is its byte offset in the string
+type visibleRune struct {
+	r    rune
+	pos  int
+	size int
+}
+
+// visibleRunes returns the runes of s skipping ANSI escape sequences
+func visibleRunes(s string) []visibleRune {
+	runes := make([]visibleRune, 0, len(s))
+	inEscapeSequence := false
+	for i, r := range s {
+		if r == '\x1b' {
+			inEscapeSequence = true
+		}
+		if inEscapeSequence {
+			if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') {
+				inEscapeSequence = false
+			}
+			continue
+		}
+		runes = append(runes, visibleRune{r: r, pos: i, size: utf8.RuneLen(r)})
+	}
+	return runes
+}
+
+// findMatches returns the byte ranges of s that match query, ignoring the ANSI
+// escape sequences. Line breaks are ignored so a match can span wrapped lines,
+// a space in the query also matches a line break because wordwrap replaces the
+// space where the line was broken. At most limit matches are returned, a
+// negative limit returns all of them.
+func findMatches(s string, query string, caseSensitive bool, limit int) [][2]int {
+	if query == "" || limit == 0 {
+		return nil
+	}
+	q := []rune(query)
+	if !caseSensitive {
+		for i := range q {
+			q[i] = unicode.ToLower(q[i])
+		}
+	}
+	runes := visibleRunes(s)
+
+	var matches [][2]int
+	for start := 0; start < len(runes); start++ {
+		if runes[start].r == '\n' {
+			continue
+		}
+		i, j := start, 0
+		for i < len(runes) && j < len(q) {
+			r := runes[i].r
+			if r == '\n' {
+				if q[j] == ' ' {
+					j++
+				}
+				i++
+				continue
+			}
+			if !caseSensitive {
+				r = unicode.ToLower(r)
+			}
+			if r != q[j] {
+				break
+			}
+			i++
+			j++
+		}
+		if j < len(q) {
+			continue
+		}
+		last := runes[i-1]
+		matches = append(matches, [2]int{runes[start].pos, last.pos + last.size})
+		if len(matches) == limit {
+			break
+		}
+		start = i - 1
+	}
+	return matches
+}
+
+// highlightMatches wraps the matches in the highlight style. The styles of the
+// original text are suspended inside a match and restored after it, and the
+// highlight is reopened after every line break so it survives the view
+// slicing the text by lines.
+func highlightMatches(s string, matches [][2]int, style string) string {
+	if len(matches) == 0 {
+		return s
+	}
+
+	var b strings.Builder
+	var activeStyle strings.Builder
+	b.Grow(len(s) + len(matches)*(len(style)+len(resetStyle)))
+
+	m := 0
+	inMatch := false
+	for i := 0; i < len(s); {
+		if m < len(matches) && !inMatch && i == matches[m][0] {
+			b.WriteString(style)
+			inMatch = true
+		}
+		if inMatch && i == matches[m][1] {
+			b.WriteString(resetStyle)
+			b.WriteString(activeStyle.String())
+			inMatch = false
+			m++
+			continue
+		}
+
+		if s[i] == '\x1b' {
+			j := i + 1
+			for j < len(s) && !(('a' <= s[j] && s[j] <= 'z') || ('A' <= s[j] && s[j] <= 'Z')) {
+				j++
+			}
+			j = min(j+1, len(s))
+			seq := s[i:j]
+			if seq == resetStyle {
+				activeStyle.Reset()
+			} else {
+				activeStyle.WriteString(seq)
+			}
+			if !inMatch {
+				b.WriteString(seq)
+			}
+			i = j
+			continue
+		}
+
+		if s[i] == '\n' && inMatch {
+			b.WriteString(resetStyle + "\n" + style)
+			i++
+			continue
+		}
+
+		b.WriteByte(s[i])
+		i++
+	}
+	if inMatch {
+		b.WriteString(resetStyle)
+		b.WriteString(activeStyle.String())
+	}
+	return b.String()
+}
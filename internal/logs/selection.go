@@ -0,0 +1,337 @@
+package logs
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"strings"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+	"github.com/filipecaixeta/logviewer/internal/logs/pipeline"
+	"github.com/filipecaixeta/logviewer/internal/logs/viewlist"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
+	"golang.design/x/clipboard"
+)
+
+const (
+	selectedDarkStyle  = "\033[48;5;237m"
+	selectedLightStyle = "\033[48;5;254m"
+	detailsSeparator   = " │ "
+)
+
+// editorClosedMsg is sent when the editor opened with
+// the selected entry exits, file is the temporary file
+type editorClosedMsg struct {
+	file string
+	err  error
+}
+
+// highlight returns the text displayed for an entry, with the
+// matches of the search and the background of the selection
+func (m *Model) highlight(l *pipeline.LogEntry) string {
+	text := m.search.highlight(l)
+	if l.Index != m.selected {
+		return text
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = selectLine(line, m.logsWidth())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// selectLine sets the background of the selection to a line, the style
+// is set again after every reset and the line is filled up to the width
+func selectLine(line string, width int) string {
+	style := selectedLightStyle
+	if config.Theme == "dark" {
+		style = selectedDarkStyle
+	}
+	fill := strings.Repeat(" ", max(0, width-lipgloss.Width(line)))
+	return style + strings.ReplaceAll(line, resetStyle, resetStyle+style) + fill + resetStyle
+}
+
+// selectedEntry returns the selected entry, nil when there is none
+func (m *Model) selectedEntry() *pipeline.LogEntry {
+	if m.selected == 0 {
+		return nil
+	}
+	return m.logEntries.GetLogEntryByIndex(m.selected)
+}
+
+// selectEntry selects an entry and scrolls the view to show it
+func (m *Model) selectEntry(l *pipeline.LogEntry) {
+	if l == nil {
+		return
+	}
+	m.selected = l.Index
+	m.detailsOffset = 0
+	m.revealSelected()
+}
+
+// selectOnScreen selects the entry on the first line of the screen,
+// or the last entry while the view follows the new entries
+func (m *Model) selectOnScreen() {
+	l := m.logEntries.GetLogEntryAtLine(m.viewStart, 0)
+	if last := m.logEntries.Last(); m.autoScroll && last != nil {
+		l = last
+		if !l.Show {
+			l = m.logEntries.Step(last.Index, true)
+		}
+	}
+	m.selectEntry(l)
+}
+
+// unselect removes the selection and closes the details pane
+func (m *Model) unselect() {
+	m.selected = 0
+	m.tree = false
+	m.fieldInput.Blur()
+	if m.details {
+		m.toggleDetails()
+	}
+}
+
+// revealSelected scrolls the view the least needed to show the selected entry
+func (m *Model) revealSelected() {
+	l := m.selectedEntry()
+	if l == nil || !l.Show || m.logHeight <= 0 {
+		return
+	}
+	top := l.CumHeight - l.Height
+	switch {
+	case top < m.viewStart:
+		m.scrollOffset = top
+	case l.CumHeight > m.viewStart+m.logHeight:
+		m.scrollOffset = min(top, l.CumHeight-m.logHeight)
+	default:
+		return
+	}
+	m.autoScroll = false
+}
+
+// entryAt returns the entry shown at the position of the screen, nil when
+// the position is in the header, the details pane or an empty line
+func (m *Model) entryAt(x, y int) *pipeline.LogEntry {
+	y -= m.headerHeight
+	if y >= m.logHeight || (m.details && x >= m.logsWidth()) {
+		return nil
+	}
+	return m.logEntries.GetLogEntryAtLine(m.viewStart, y)
+}
+
+// toggleDetails shows or hides the pane with all the fields of the
+// selected entry, the entries are formatted again to fit next to it
+func (m *Model) toggleDetails() {
+	m.details = !m.details
+	m.detailsOffset = 0
+	m.tree = m.tree && m.details
+	_ = m.pipeline.SetWidth(uint(m.logsWidth()))
+	m.runPipeline(m.pipeline.RunWidthChanged)
+}
+
+// logsWidth returns the width of the entries, the
+// details pane takes half of the screen when it is shown
+func (m *Model) logsWidth() int {
+	if !m.details {
+		return m.common.Width
+	}
+	return max(0, m.common.Width-m.detailsWidth()-runewidth.StringWidth(detailsSeparator))
+}
+
+func (m *Model) detailsWidth() int {
+	return m.common.Width / 2
+}
+
+func (m *Model) scrollDetails(n int) {
+	m.detailsOffset = max(0, m.detailsOffset+n)
+}
+
+// withDetails places the details of the selected entry next to the logs,
+// the fields are shown as a tree
+func (m *Model) withDetails(logsView string, l *pipeline.LogEntry, height int) string {
+	var details []string
+	if nodes := m.treeNodes(); nodes != nil {
+		details = m.treeLines(nodes, m.detailsWidth())
+		if m.tree {
+			// the cursor is kept on the screen
+			cursor := m.treeCursorIndex(nodes)
+			m.detailsOffset = max(min(m.detailsOffset, cursor), cursor-height+1)
+		}
+	} else {
+		details = strings.Split(entryDetails(l, m.detailsWidth(), true), "\n")
+	}
+	m.detailsOffset = min(m.detailsOffset, max(0, len(details)-height))
+	details = details[m.detailsOffset:]
+
+	logLines := strings.Split(logsView, "\n")
+	var b strings.Builder
+	for i := 0; i < height; i++ {
+		var left, right string
+		if i < len(logLines) {
+			left = logLines[i]
+		}
+		if i < len(details) {
+			right = details[i]
+		}
+		b.WriteString(left + strings.Repeat(" ", max(0, m.logsWidth()-lipgloss.Width(left))))
+		b.WriteString(config.ListStyle.Render(detailsSeparator))
+		b.WriteString(right + resetStyle + "\n")
+	}
+	return b.String()
+}
+
+// entryDetails formats every field of the entry and its source metadata,
+// the returned fields of the view are ignored
+func entryDetails(l *pipeline.LogEntry, width int, highlight bool) string {
+	if l.Marker && !highlight {
+		return l.Raw
+	}
+	d := *l
+	d.Show = true
+	d.Json = detailsJson(l)
+	f := pipeline.LogFormat{Width: uint(width), Highlight: highlight}
+	_ = f.RunReturnedFieldsAndFormat(&d)
+	return d.Formatted
+}
+
+// detailsJson returns the json of the entry with its source metadata
+// under meta, unless the json has a meta field
+func detailsJson(l *pipeline.LogEntry) map[string]interface{} {
+	if _, ok := l.Json["meta"]; len(l.Json) == 0 || len(l.Meta) == 0 || ok {
+		return l.Json
+	}
+	meta := make(map[string]interface{}, len(l.Meta))
+	for k, v := range l.Meta {
+		meta[k] = v
+	}
+	j := maps.Clone(l.Json)
+	j["meta"] = meta
+	return j
+}
+
+// updateSelection handles the keys acting on the selected entry,
+// it returns false when the key isn't one of them
+func (m *Model) updateSelection(msg tea.KeyMsg) (tea.Cmd, bool) {
+	l := m.selectedEntry()
+	if l == nil {
+		m.unselect()
+		return nil, false
+	}
+	switch {
+	case key.Matches(msg, selectionKeys.Up):
+		m.selectEntry(m.logEntries.Step(l.Index, true))
+	case key.Matches(msg, selectionKeys.Down):
+		m.selectEntry(m.logEntries.Step(l.Index, false))
+	case key.Matches(msg, selectionKeys.Details):
+		m.toggleDetails()
+	case key.Matches(msg, selectionKeys.DetailsUp):
+		m.scrollDetails(-m.logHeight / 2)
+	case key.Matches(msg, selectionKeys.DetailsDown):
+		m.scrollDetails(m.logHeight / 2)
+	case key.Matches(msg, selectionKeys.Tree):
+		m.openTree()
+	case key.Matches(msg, selectionKeys.Copy):
+		m.copyToClipboard(m.formatEntry(l))
+	case key.Matches(msg, selectionKeys.CopyRaw):
+		m.copyToClipboard(l.Raw)
+	case key.Matches(msg, selectionKeys.CopyField):
+		m.fieldErr = ""
+		m.fieldInput.SetValue("")
+		return m.fieldInput.Focus(), true
+	case key.Matches(msg, selectionKeys.Edit):
+		return m.openEditor(l), true
+	case key.Matches(msg, selectionKeys.Unselect):
+		m.unselect()
+	default:
+		return nil, false
+	}
+	return nil, true
+}
+
+// updateFieldInput reads the name of the field of the selected entry to copy
+func (m *Model) updateFieldInput(msg tea.KeyMsg) tea.Cmd {
+	switch {
+	case key.Matches(msg, searchKeys.Confirm):
+		l := m.selectedEntry()
+		if l == nil {
+			m.fieldInput.Blur()
+			return nil
+		}
+		field := strings.TrimSpace(m.fieldInput.Value())
+		value, ok := l.Field(field)
+		if !ok {
+			m.fieldErr = fmt.Sprintf("the entry has no field %s", field)
+			return nil
+		}
+		m.fieldInput.Blur()
+		m.copyToClipboard(pipeline.FieldText(value))
+		return nil
+	case key.Matches(msg, searchKeys.Cancel):
+		m.fieldInput.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.fieldInput, cmd = m.fieldInput.Update(msg)
+	return cmd
+}
+
+// formatEntry formats the entry as it is displayed, without colors
+func (m *Model) formatEntry(l *pipeline.LogEntry) string {
+	if l.Marker {
+		return l.Raw
+	}
+	lCopy := *l
+	lCopy.Show = true
+	t := pipeline.LogFormat{
+		ReturnedFields: viewlist.DisplayedView.ReturnedFields,
+		Display:        m.pipeline.Display(),
+	}
+	_ = t.RunReturnedFieldsAndFormat(&lCopy)
+	return lCopy.Formatted
+}
+
+func (m *Model) copyToClipboard(text string) {
+	if err := clipboard.Init(); err != nil {
+		m.notice = fmt.Sprintf("clipboard: %v", err)
+		return
+	}
+	clipboard.Write(clipboard.FmtText, []byte(text))
+}
+
+// openEditor opens every field of the entry in $EDITOR, vi when it isn't set
+func (m *Model) openEditor(l *pipeline.LogEntry) tea.Cmd {
+	ext := "*.log"
+	if len(l.Json) > 0 {
+		ext = "*.json"
+	}
+	f, err := os.CreateTemp("", "logviewer-"+ext)
+	if err != nil {
+		m.notice = fmt.Sprintf("editor: %v", err)
+		return nil
+	}
+	_, err = f.WriteString(entryDetails(l, 0, false) + "\n")
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		os.Remove(f.Name())
+		m.notice = fmt.Sprintf("editor: %v", err)
+		return nil
+	}
+
+	editor := strings.Fields(os.Getenv("EDITOR"))
+	if len(editor) == 0 {
+		editor = []string{"vi"}
+	}
+	cmd := exec.Command(editor[0], append(editor[1:], f.Name())...)
+	return tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return editorClosedMsg{file: f.Name(), err: err}
+	})
+}
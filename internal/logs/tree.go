@@ -0,0 +1,325 @@
+package logs
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+	"github.com/filipecaixeta/logviewer/internal/logs/json_format"
+	"github.com/filipecaixeta/logviewer/internal/logs/pipeline"
+	"github.com/filipecaixeta/logviewer/internal/logs/viewlist"
+
+	"github.com/charmbracelet/bubbles/key"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// treeNode is a line of the tree of the selected entry, a field of an
+// object or an item of an array. Its path holds the keys, as strings,
+// and the indexes, as ints, from the root of the entry
+type treeNode struct {
+	path      []interface{}
+	value     interface{}
+	container bool
+	collapsed bool
+}
+
+func (n treeNode) depth() int {
+	return len(n.path) - 1
+}
+
+// flattenTree returns the nodes of the tree that are shown, the
+// children of the collapsed paths are left out
+func flattenTree(value interface{}, path []interface{}, collapsed map[string]bool, nodes []treeNode) []treeNode {
+	var children []interface{}
+	var values []interface{}
+	switch v := value.(type) {
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			children = append(children, k)
+			values = append(values, v[k])
+		}
+	case []interface{}:
+		for i, item := range v {
+			children = append(children, i)
+			values = append(values, item)
+		}
+	}
+
+	for i, child := range children {
+		n := treeNode{
+			path:  append(path[:len(path):len(path)], child),
+			value: values[i],
+		}
+		switch values[i].(type) {
+		case map[string]interface{}, []interface{}:
+			n.container = true
+			n.collapsed = collapsed[pathString(n.path)]
+		}
+		nodes = append(nodes, n)
+		if n.container && !n.collapsed {
+			nodes = flattenTree(n.value, n.path, collapsed, nodes)
+		}
+	}
+	return nodes
+}
+
+// pathString returns the path as a dotted field, the collapsed paths are
+// remembered by it so they stay collapsed for the other entries
+func pathString(path []interface{}) string {
+	parts := make([]string, len(path))
+	for i, p := range path {
+		parts[i] = fmt.Sprint(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// treeLines returns the lines of the tree of the entry, the
+// line of the cursor is highlighted while the tree is focused
+func (m *Model) treeLines(nodes []treeNode, width int) []string {
+	style := &json_format.LightStyle
+	if config.Theme == "dark" {
+		style = &json_format.DarkStyle
+	}
+	lines := make([]string, len(nodes))
+	for i, n := range nodes {
+		marker := "  "
+		if n.container && n.collapsed {
+			marker = "▸ "
+		} else if n.container {
+			marker = "▾ "
+		}
+		prefix := strings.Repeat("  ", n.depth()) + marker
+		name := runewidth.Truncate(fmt.Sprint(n.path[len(n.path)-1]), max(0, width-runewidth.StringWidth(prefix)), "…")
+		line := prefix + style.Key + name + resetStyle
+
+		value, color := treeValue(n, style)
+		if rest := width - runewidth.StringWidth(prefix+name+": "); value != "" && rest > 0 {
+			line += ": " + color + runewidth.Truncate(value, rest, "…") + resetStyle
+		}
+		if m.tree && i == m.treeCursorIndex(nodes) {
+			line = selectLine(line, width)
+		}
+		lines[i] = line
+	}
+	return lines
+}
+
+// treeValue returns the text of the value of a node and its color,
+// the expanded objects and arrays have no value, their fields follow
+func treeValue(n treeNode, style *json_format.Style) (string, string) {
+	switch v := n.value.(type) {
+	case map[string]interface{}:
+		if !n.collapsed {
+			return "", ""
+		}
+		return fmt.Sprintf("{…} %d keys", len(v)), style.Generic
+	case []interface{}:
+		if !n.collapsed {
+			return "", ""
+		}
+		return fmt.Sprintf("[…] %d items", len(v)), style.Generic
+	case nil:
+		return "null", style.Null
+	case bool:
+		return strconv.FormatBool(v), style.Boolean
+	case float64, int64, int:
+		return pipeline.FieldText(v), style.Numeric
+	default:
+		b, _ := json.Marshal(pipeline.FieldText(v))
+		return string(b), style.String
+	}
+}
+
+// treeNodes returns the nodes of the selected entry, nil when it has no fields
+func (m *Model) treeNodes() []treeNode {
+	l := m.selectedEntry()
+	if l == nil || len(l.Json) == 0 {
+		return nil
+	}
+	return flattenTree(detailsJson(l), nil, m.collapsed, nil)
+}
+
+// treeCursorIndex returns the position of the cursor in the nodes, the
+// cursor is kept by path so it stays on the same field for other entries
+func (m *Model) treeCursorIndex(nodes []treeNode) int {
+	for i, n := range nodes {
+		if pathString(n.path) == m.treeCursor {
+			return i
+		}
+	}
+	return 0
+}
+
+// openTree focuses the tree of the selected entry, the details pane is opened
+func (m *Model) openTree() {
+	l := m.selectedEntry()
+	if l == nil || len(l.Json) == 0 {
+		m.notice = "the entry has no fields"
+		return
+	}
+	if !m.details {
+		m.toggleDetails()
+	}
+	m.tree = true
+}
+
+// updateTree handles the keys of the tree, it returns
+// false when the key isn't one of them
+func (m *Model) updateTree(msg tea.KeyMsg) (tea.Cmd, bool) {
+	nodes := m.treeNodes()
+	if len(nodes) == 0 {
+		m.tree = false
+		return nil, false
+	}
+	i := m.treeCursorIndex(nodes)
+	n := nodes[i]
+	moveTo := func(i int) {
+		i = max(0, min(len(nodes)-1, i))
+		m.treeCursor = pathString(nodes[i].path)
+	}
+
+	switch {
+	case key.Matches(msg, treeKeys.Up):
+		moveTo(i - 1)
+	case key.Matches(msg, treeKeys.Down):
+		moveTo(i + 1)
+	case key.Matches(msg, treeKeys.Toggle):
+		if n.container {
+			m.setCollapsed(n, !n.collapsed)
+		}
+	case key.Matches(msg, treeKeys.Collapse):
+		if n.container && !n.collapsed {
+			m.setCollapsed(n, true)
+		} else if n.depth() > 0 {
+			m.treeCursor = pathString(n.path[:len(n.path)-1])
+		}
+	case key.Matches(msg, treeKeys.Expand):
+		if n.container && n.collapsed {
+			m.setCollapsed(n, false)
+		}
+	case key.Matches(msg, treeKeys.AddField):
+		m.addReturnedField(n)
+	case key.Matches(msg, treeKeys.FilterValue):
+		m.filterOnValue(n)
+	case key.Matches(msg, treeKeys.Back):
+		m.tree = false
+	default:
+		return nil, false
+	}
+	m.treeCursor = pathString(nodes[m.treeCursorIndex(nodes)].path)
+	return nil, true
+}
+
+func (m *Model) setCollapsed(n treeNode, collapsed bool) {
+	if collapsed {
+		m.collapsed[pathString(n.path)] = true
+	} else {
+		delete(m.collapsed, pathString(n.path))
+	}
+}
+
+// addReturnedField adds the field of the node to the returned fields of
+// the displayed view, the items of an array add the whole array
+func (m *Model) addReturnedField(n treeNode) {
+	var parts []interface{}
+	for _, p := range n.path {
+		if _, ok := p.(int); ok {
+			break
+		}
+		parts = append(parts, p)
+	}
+	field := pathString(parts)
+	for _, f := range viewlist.DisplayedView.ReturnedFields {
+		if f == field {
+			return
+		}
+	}
+	fields := append([]string(nil), viewlist.DisplayedView.ReturnedFields...)
+	fields = append(fields, field)
+	viewlist.DisplayedView.ReturnedFields = fields
+	_ = m.pipeline.SetReturnedFields(fields)
+	m.runPipeline(m.pipeline.RunReturnedFieldsChanged)
+}
+
+// filterOnValue adds a condition matching the value of the node
+// to the filter of the displayed view
+func (m *Model) filterOnValue(n treeNode) {
+	l := m.selectedEntry()
+	if l == nil {
+		return
+	}
+	literal, ok := exprLiteral(n.value)
+	if !ok {
+		m.notice = "only strings, numbers, booleans and nulls can be filtered on"
+		return
+	}
+	root, path := "json", n.path
+	if _, inJson := l.Json["meta"]; path[0] == "meta" && !inJson {
+		root, path = "meta", path[1:]
+	}
+	cond := exprPath(root, path) + " == " + literal
+
+	filter := strings.TrimSpace(viewlist.DisplayedView.Filter)
+	if filter != "" {
+		filter = "(" + filter + ") and " + cond
+	} else {
+		filter = cond
+	}
+	viewlist.DisplayedView.Filter = filter
+	_ = m.pipeline.SetFilter(filter)
+	m.runPipeline(m.pipeline.RunFilterChanged)
+}
+
+var identifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// exprKeywords can't be used as the name of a field after a dot
+var exprKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "in": true, "matches": true,
+	"contains": true, "startsWith": true, "endsWith": true,
+	"nil": true, "true": true, "false": true, "let": true,
+}
+
+// exprPath returns the expression accessing the path in the variable root
+func exprPath(root string, path []interface{}) string {
+	var b strings.Builder
+	b.WriteString(root)
+	for _, p := range path {
+		switch p := p.(type) {
+		case int:
+			fmt.Fprintf(&b, "[%d]", p)
+		case string:
+			if identifier.MatchString(p) && !exprKeywords[p] {
+				b.WriteString("." + p)
+			} else {
+				b.WriteString("[" + strconv.Quote(p) + "]")
+			}
+		}
+	}
+	return b.String()
+}
+
+// exprLiteral returns the value as an expr literal, false
+// when the value can't be compared
+func exprLiteral(value interface{}) (string, bool) {
+	switch v := value.(type) {
+	case nil:
+		return "nil", true
+	case string:
+		return strconv.Quote(v), true
+	case bool:
+		return strconv.FormatBool(v), true
+	case float64, int64, int:
+		return pipeline.FieldText(v), true
+	}
+	return "", false
+}
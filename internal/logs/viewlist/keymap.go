@@ -3,12 +3,15 @@ package viewlist
 import "github.com/charmbracelet/bubbles/key"
 
 type keyMap struct {
-	Up     key.Binding
-	Down   key.Binding
-	Select key.Binding
-	New    key.Binding
-	Quit   key.Binding
-	Esc    key.Binding
+	Up        key.Binding
+	Down      key.Binding
+	Select    key.Binding
+	New       key.Binding
+	Duplicate key.Binding
+	Rename    key.Binding
+	Delete    key.Binding
+	Quit      key.Binding
+	Esc       key.Binding
 }
 
 var Keys = keyMap{
@@ -26,7 +29,19 @@ var Keys = keyMap{
 	),
 	New: key.NewBinding(
 		key.WithKeys("c"),
-		key.WithHelp("c", "create view"),
+		key.WithHelp("c", "create"),
+	),
+	Duplicate: key.NewBinding(
+		key.WithKeys("d"),
+		key.WithHelp("d", "duplicate"),
+	),
+	Rename: key.NewBinding(
+		key.WithKeys("r"),
+		key.WithHelp("r", "rename"),
+	),
+	Delete: key.NewBinding(
+		key.WithKeys("x"),
+		key.WithHelp("x", "delete"),
 	),
 	Quit: key.NewBinding(
 		key.WithKeys("ctrl+c", "q"),
@@ -39,7 +54,30 @@ var Keys = keyMap{
 }
 
 func (k keyMap) ShortHelp() []key.Binding {
-	return []key.Binding{k.Up, k.Down, k.Select, k.Esc, k.Quit}
+	return []key.Binding{k.Up, k.Down, k.Select, k.New, k.Duplicate, k.Rename, k.Delete, k.Esc, k.Quit}
 }
 
 func (k keyMap) FullHelp() [][]key.Binding { return nil }
+
+type promptKeyMap struct {
+	Confirm key.Binding
+	Cancel  key.Binding
+}
+
+// PromptKeys are the keys of the prompts to name and delete views
+var PromptKeys = promptKeyMap{
+	Confirm: key.NewBinding(
+		key.WithKeys("enter"),
+		key.WithHelp("enter", "confirm"),
+	),
+	Cancel: key.NewBinding(
+		key.WithKeys("esc"),
+		key.WithHelp("esc", "cancel"),
+	),
+}
+
+func (k promptKeyMap) ShortHelp() []key.Binding {
+	return []key.Binding{k.Confirm, k.Cancel}
+}
+
+func (k promptKeyMap) FullHelp() [][]key.Binding { return nil }
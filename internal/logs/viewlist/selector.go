@@ -0,0 +1,162 @@
+package viewlist
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+)
+
+var (
+	// labelTerm is a term of a label selector, key=glob or key!=glob. The
+	// keys have no dots and the globs no quotes, so expressions like
+	// meta.container != "istio-proxy" aren't taken for label terms
+	labelTerm = regexp.MustCompile("^\\s*([\\w-]+)\\s*(!?=)\\s*([^=,\"'`]*?)\\s*$")
+	// metaKey finds the metadata keys used by an expression selector
+	metaKey = regexp.MustCompile(`meta(?:\.(\w+)|\[["'](\w+)["']\])`)
+)
+
+// selector matches the metadata of the source, it is either a list of
+// label terms like `namespace=prod-*, container!=istio-proxy` or an expr
+// expression like `meta.namespace startsWith "prod" and meta.file endsWith ".log"`
+type selector struct {
+	terms   []labelMatch
+	program *vm.Program
+	// keys are the metadata keys checked by the selector, the more
+	// keys a selector checks the better it matches
+	keys []string
+}
+
+type labelMatch struct {
+	key     string
+	negate  bool
+	pattern string
+	glob    *regexp.Regexp
+}
+
+func compileSelector(s string) (*selector, error) {
+	if terms, ok := labelTerms(s); ok {
+		sel := &selector{terms: terms}
+		for _, t := range terms {
+			sel.keys = append(sel.keys, t.key)
+		}
+		return sel, nil
+	}
+
+	program, err := expr.Compile(s, expr.AsBool())
+	if err != nil {
+		return nil, err
+	}
+	sel := &selector{program: program}
+	seen := map[string]bool{}
+	for _, m := range metaKey.FindAllStringSubmatch(s, -1) {
+		key := m[1] + m[2]
+		if !seen[key] {
+			seen[key] = true
+			sel.keys = append(sel.keys, key)
+		}
+	}
+	return sel, nil
+}
+
+// labelTerms parses a label selector, ok is false when it is an expression
+func labelTerms(s string) ([]labelMatch, bool) {
+	var terms []labelMatch
+	for _, term := range strings.Split(s, ",") {
+		m := labelTerm.FindStringSubmatch(term)
+		if m == nil {
+			return nil, false
+		}
+		terms = append(terms, labelMatch{key: m[1], negate: m[2] == "!=", pattern: m[3], glob: globToRegexp(m[3])})
+	}
+	return terms, true
+}
+
+// globToRegexp converts a glob where * matches any text, even /
+func globToRegexp(glob string) *regexp.Regexp {
+	quoted := regexp.QuoteMeta(glob)
+	quoted = strings.ReplaceAll(quoted, `\*`, ".*")
+	quoted = strings.ReplaceAll(quoted, `\?`, ".")
+	return regexp.MustCompile("^" + quoted + "$")
+}
+
+func (s *selector) match(meta map[string]string) (bool, error) {
+	if s.program == nil {
+		for _, t := range s.terms {
+			value, ok := meta[t.key]
+			if (ok && t.glob.MatchString(value)) == t.negate {
+				return false, nil
+			}
+		}
+		return true, nil
+	}
+
+	r, err := vm.Run(s.program, map[string]interface{}{"meta": meta})
+	if err != nil {
+		return false, err
+	}
+	return r.(bool), nil
+}
+
+// reason describes the metadata that matched the selector
+func (s *selector) reason(meta map[string]string) string {
+	var parts []string
+	for _, t := range s.terms {
+		if t.negate {
+			parts = append(parts, t.key+"!="+t.pattern)
+			continue
+		}
+		parts = append(parts, t.key+"="+meta[t.key])
+	}
+	if s.program != nil {
+		keys := append([]string(nil), s.keys...)
+		sort.Strings(keys)
+		for _, key := range keys {
+			if value, ok := meta[key]; ok {
+				parts = append(parts, key+"="+value)
+			}
+		}
+		if len(parts) == 0 {
+			return "selector matched"
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Best returns the view whose selector matches the metadata and checks
+// the most keys, the first one in the config when there is a tie, and
+// why it was chosen. Views without a selector are never chosen. The
+// selectors that don't compile or fail are skipped and their errors returned
+func Best(views []config.View, meta map[string]string) (*config.View, string, error) {
+	var best *config.View
+	var bestSel *selector
+	var errs []error
+	for i := range views {
+		if strings.TrimSpace(views[i].Selector) == "" {
+			continue
+		}
+		sel, err := compileSelector(views[i].Selector)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("selector of view %s: %w", views[i].Name, err))
+			continue
+		}
+		ok, err := sel.match(meta)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("selector of view %s: %w", views[i].Name, err))
+			continue
+		}
+		if ok && (best == nil || len(sel.keys) > len(bestSel.keys)) {
+			best, bestSel = &views[i], sel
+		}
+	}
+	if best == nil {
+		return nil, "", errors.Join(errs...)
+	}
+	return best, bestSel.reason(meta), errors.Join(errs...)
+}
@@ -0,0 +1,178 @@
+package viewlist
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+)
+
+func TestLabelTerms(t *testing.T) {
+	tests := []struct {
+		selector string
+		want     []labelMatch
+		ok       bool
+	}{
+		{"namespace=prod-*", []labelMatch{{key: "namespace", pattern: "prod-*"}}, true},
+		{" namespace = prod , container!=istio-proxy ", []labelMatch{
+			{key: "namespace", pattern: "prod"},
+			{key: "container", negate: true, pattern: "istio-proxy"},
+		}, true},
+		{"file=/var/log/*.log", []labelMatch{{key: "file", pattern: "/var/log/*.log"}}, true},
+		{"container=", []labelMatch{{key: "container", pattern: ""}}, true},
+		// expressions
+		{`meta.container != "istio-proxy"`, nil, false},
+		{`meta.namespace == "prod"`, nil, false},
+		{`container != "istio-proxy"`, nil, false},
+		{`container='api'`, nil, false},
+		{"container=`api`", nil, false},
+		{`meta.file endsWith ".log"`, nil, false},
+		{"namespace=prod, meta.pod startsWith 'api'", nil, false},
+	}
+	for _, tt := range tests {
+		terms, ok := labelTerms(tt.selector)
+		if ok != tt.ok {
+			t.Errorf("labelTerms(%q) ok = %v, want %v", tt.selector, ok, tt.ok)
+			continue
+		}
+		for i := range terms {
+			terms[i].glob = nil
+		}
+		if !reflect.DeepEqual(terms, tt.want) {
+			t.Errorf("labelTerms(%q) = %+v, want %+v", tt.selector, terms, tt.want)
+		}
+	}
+}
+
+func TestGlobToRegexp(t *testing.T) {
+	tests := []struct {
+		glob, value string
+		want        bool
+	}{
+		{"prod-*", "prod-eu", true},
+		{"prod-*", "prod-", true},
+		{"prod-*", "staging-prod-eu", false},
+		{"*.log", "/var/log/app/access.log", true},
+		{"api-?", "api-1", true},
+		{"api-?", "api-12", false},
+		{"a.b", "a.b", true},
+		{"a.b", "axb", false},
+		{"(x)", "(x)", true},
+		{"", "", true},
+		{"", "x", false},
+	}
+	for _, tt := range tests {
+		if got := globToRegexp(tt.glob).MatchString(tt.value); got != tt.want {
+			t.Errorf("glob %q matching %q = %v, want %v", tt.glob, tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestCompileSelector(t *testing.T) {
+	tests := []struct {
+		selector string
+		keys     []string
+		expr     bool
+	}{
+		{"namespace=prod, container!=istio-proxy", []string{"namespace", "container"}, false},
+		{`meta.container != "istio-proxy"`, []string{"container"}, true},
+		{`meta.namespace == "prod" and meta["container"] == "api" or meta.namespace == "dev"`, []string{"namespace", "container"}, true},
+		{`text contains "x"`, nil, true},
+	}
+	for _, tt := range tests {
+		sel, err := compileSelector(tt.selector)
+		if err != nil {
+			t.Errorf("compileSelector(%q): %v", tt.selector, err)
+			continue
+		}
+		if (sel.program != nil) != tt.expr {
+			t.Errorf("compileSelector(%q) is an expression = %v, want %v", tt.selector, sel.program != nil, tt.expr)
+		}
+		if !reflect.DeepEqual(sel.keys, tt.keys) {
+			t.Errorf("compileSelector(%q) keys = %q, want %q", tt.selector, sel.keys, tt.keys)
+		}
+	}
+
+	for _, s := range []string{`meta.namespace ==`, `meta.namespace + 1`} {
+		if _, err := compileSelector(s); err == nil {
+			t.Errorf("compileSelector(%q) compiled", s)
+		}
+	}
+}
+
+func TestSelectorMatch(t *testing.T) {
+	meta := map[string]string{"namespace": "prod-eu", "container": "api"}
+	tests := []struct {
+		selector string
+		want     bool
+	}{
+		{"namespace=prod-*", true},
+		{"namespace=prod-*, container=api", true},
+		{"namespace=prod-*, container=web", false},
+		{"container!=istio-proxy", true},
+		{"container!=api", false},
+		// a missing key doesn't match, unless the term is negated
+		{"pod=api-*", false},
+		{"pod!=api-*", true},
+		{`meta.container != "istio-proxy"`, true},
+		{`meta.container != "api"`, false},
+		{`meta.namespace startsWith "prod" and meta.container == "api"`, true},
+	}
+	for _, tt := range tests {
+		sel, err := compileSelector(tt.selector)
+		if err != nil {
+			t.Fatalf("compileSelector(%q): %v", tt.selector, err)
+		}
+		got, err := sel.match(meta)
+		if err != nil {
+			t.Errorf("match(%q): %v", tt.selector, err)
+		}
+		if got != tt.want {
+			t.Errorf("match(%q) = %v, want %v", tt.selector, got, tt.want)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	views := []config.View{
+		{Name: "no selector"},
+		{Name: "prod", Selector: "namespace=prod-*"},
+		{Name: "prod api", Selector: "namespace=prod-*, container=api"},
+		{Name: "prod api again", Selector: `meta.namespace startsWith "prod" and meta.container == "api"`},
+		{Name: "not istio", Selector: `meta.container != "istio-proxy" and meta.namespace == "mesh"`},
+		{Name: "bad", Selector: `meta.namespace ==`},
+		{Name: "failing", Selector: `meta.namespace + 1 > 0`},
+	}
+
+	tests := []struct {
+		meta   map[string]string
+		want   string
+		reason string
+	}{
+		// the selector checking the most keys, the first one on a tie
+		{map[string]string{"namespace": "prod-eu", "container": "api"}, "prod api", "namespace=prod-eu, container=api"},
+		{map[string]string{"namespace": "prod-eu", "container": "web"}, "prod", "namespace=prod-eu"},
+		{map[string]string{"namespace": "mesh", "container": "app"}, "not istio", "container=app, namespace=mesh"},
+		// the container the view is meant to exclude
+		{map[string]string{"namespace": "mesh", "container": "istio-proxy"}, "", ""},
+		{map[string]string{}, "", ""},
+	}
+	for _, tt := range tests {
+		view, reason, err := Best(views, tt.meta)
+		name := ""
+		if view != nil {
+			name = view.Name
+		}
+		if name != tt.want || reason != tt.reason {
+			t.Errorf("Best(%v) = %q (%s), want %q (%s)", tt.meta, name, reason, tt.want, tt.reason)
+		}
+		// the errors of the broken selectors are joined, the other views are still chosen
+		if err == nil || !strings.Contains(err.Error(), "selector of view bad") {
+			t.Errorf("Best(%v) error = %v, want the error of view bad", tt.meta, err)
+		}
+		if tt.meta["namespace"] != "" && !strings.Contains(err.Error(), "selector of view failing") {
+			t.Errorf("Best(%v) error = %v, want the error of view failing", tt.meta, err)
+		}
+	}
+}
@@ -1,8 +1,10 @@
 package viewlist
 
 import (
+	"errors"
 	"fmt"
 	"io"
+	"strings"
 
 	"github.com/filipecaixeta/logviewer/internal/common"
 	"github.com/filipecaixeta/logviewer/internal/config"
@@ -10,6 +12,7 @@ import (
 
 	"github.com/charmbracelet/bubbles/key"
 	"github.com/charmbracelet/bubbles/list"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/charmbracelet/lipgloss"
 )
@@ -29,12 +32,36 @@ type Model struct {
 	Visible  bool
 	viewList list.Model
 	Height   int
+	// auto is the view chosen by its selector and autoReason why
+	auto       *config.View
+	autoReason string
+
+	// action is the change being made to the selected view,
+	// input asks for the name of the view
+	action action
+	input  textinput.Model
+	err    string
 }
 
+type action int
+
+const (
+	actionNone action = iota
+	actionNew
+	actionDuplicate
+	actionRename
+	actionDelete
+)
+
 func (m *Model) Init() tea.Cmd {
 	return nil
 }
 
+// Editing reports whether a prompt of the list is open
+func (m *Model) Editing() bool {
+	return m.action != actionNone
+}
+
 func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var cmd tea.Cmd
 	switch msg := msg.(type) {
@@ -42,6 +69,11 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.viewList.SetWidth(msg.Width)
 		return m, nil
 	case tea.KeyMsg:
+		if m.action != actionNone {
+			return m, m.updateAction(msg)
+		}
+		m.err = ""
+		selected, _ := m.viewList.SelectedItem().(*config.View)
 		switch {
 		case key.Matches(msg, Keys.Up), key.Matches(msg, Keys.Down):
 			m.viewList, cmd = m.viewList.Update(msg)
@@ -49,11 +81,17 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, tea.Quit
 		case key.Matches(msg, Keys.Esc):
 			m.Visible = false
-			// case key.Matches(msg, keys.New):
-			// 	m.common.SetState(state.StateCreateView)
+		case key.Matches(msg, Keys.New):
+			return m, m.startAction(actionNew, "")
+		case key.Matches(msg, Keys.Duplicate) && selected != nil:
+			return m, m.startAction(actionDuplicate, selected.Name+" copy")
+		case key.Matches(msg, Keys.Rename) && selected != nil:
+			return m, m.startAction(actionRename, selected.Name)
+		case key.Matches(msg, Keys.Delete) && selected != nil:
+			m.action = actionDelete
 		case key.Matches(msg, Keys.Select):
-			if len(m.viewList.Items()) != 0 {
-				CurrentView = m.viewList.SelectedItem().(*config.View)
+			if selected != nil {
+				CurrentView = selected
 				DisplayedView = *CurrentView
 			}
 			m.common.SetState(state.StateLoadView)
@@ -63,11 +101,190 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, cmd
 }
 
+func (m *Model) startAction(a action, name string) tea.Cmd {
+	m.action = a
+	m.input.SetValue(name)
+	m.input.CursorEnd()
+	return m.input.Focus()
+}
+
+func (m *Model) updateAction(msg tea.KeyMsg) tea.Cmd {
+	if m.action == actionDelete {
+		if msg.String() == "y" {
+			m.err = errString(m.deleteView())
+		}
+		m.action = actionNone
+		return nil
+	}
+
+	switch {
+	case key.Matches(msg, PromptKeys.Cancel):
+		m.action = actionNone
+		m.err = ""
+		m.input.Blur()
+		return nil
+	case key.Matches(msg, PromptKeys.Confirm):
+		name := strings.TrimSpace(m.input.Value())
+		if err := m.checkName(name); err != nil {
+			m.err = err.Error()
+			return nil
+		}
+		var err error
+		switch m.action {
+		case actionNew:
+			err = m.addView(config.View{Name: name})
+		case actionDuplicate:
+			v := *m.viewList.SelectedItem().(*config.View)
+			v.Name = name
+			err = m.addView(v)
+		case actionRename:
+			err = m.renameView(name)
+		}
+		m.err = errString(err)
+		m.action = actionNone
+		m.input.Blur()
+		return nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return cmd
+}
+
+func (m *Model) checkName(name string) error {
+	if name == "" {
+		return errors.New("the name of the view can't be empty")
+	}
+	selected, _ := m.viewList.SelectedItem().(*config.View)
+	for i := range m.common.Cfg.Views {
+		v := &m.common.Cfg.Views[i]
+		if v.Name == name && !(m.action == actionRename && v == selected) {
+			return fmt.Errorf("there is already a view named %s", name)
+		}
+	}
+	return nil
+}
+
+// addView saves a new view and displays it
+func (m *Model) addView(v config.View) error {
+	err := m.change(-1, func() error { return m.common.Cfg.AddView(v) })
+	if err != nil {
+		return err
+	}
+	views := m.common.Cfg.Views
+	m.viewList.Select(len(views) - 1)
+	CurrentView = &views[len(views)-1]
+	DisplayedView = *CurrentView
+	m.common.SetState(state.StateLoadView)
+	m.Visible = false
+	return nil
+}
+
+func (m *Model) renameView(name string) error {
+	i := m.viewList.Index()
+	v := m.common.Cfg.Views[i]
+	v.Name = name
+	if err := m.change(-1, func() error { return m.common.Cfg.UpdateView(i, v) }); err != nil {
+		return err
+	}
+	if CurrentView == &m.common.Cfg.Views[i] {
+		DisplayedView.Name = name
+	}
+	return nil
+}
+
+// deleteView deletes the selected view, if it is displayed
+// the logs are displayed without a view
+func (m *Model) deleteView() error {
+	i := m.viewList.Index()
+	displayed := CurrentView == &m.common.Cfg.Views[i]
+	if err := m.change(i, func() error { return m.common.Cfg.DeleteView(i) }); err != nil {
+		return err
+	}
+	if displayed {
+		DisplayedView = config.View{}
+		m.common.SetState(state.StateLoadView)
+	}
+	return nil
+}
+
+// change runs op, which changes the views of the config, and points
+// CurrentView and the auto view to the same views after it. removed is
+// the index of the view removed, -1 if none was
+func (m *Model) change(removed int, op func() error) error {
+	views := &m.common.Cfg.Views
+	current, auto := indexOf(*views, CurrentView), indexOf(*views, m.auto)
+	if err := op(); err != nil {
+		return err
+	}
+	repoint := func(i int) *config.View {
+		if i < 0 || i == removed {
+			return nil
+		}
+		if removed >= 0 && i > removed {
+			i--
+		}
+		return &(*views)[i]
+	}
+	CurrentView, m.auto = repoint(current), repoint(auto)
+	m.viewList.SetItems(toListItem(*views))
+	return nil
+}
+
+func indexOf(views []config.View, v *config.View) int {
+	for i := range views {
+		if &views[i] == v {
+			return i
+		}
+	}
+	return -1
+}
+
+// SaveCurrentView applies update to the current view and saves it in the config file
+func SaveCurrentView(cfg *config.Config, update func(v *config.View)) error {
+	i := indexOf(cfg.Views, CurrentView)
+	if i < 0 {
+		return errors.New("there is no view to save, create one in the list of views")
+	}
+	v := *CurrentView
+	update(&v)
+	return cfg.UpdateView(i, v)
+}
+
+func errString(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// SetAuto marks the view chosen by its selector, the cursor is moved to it
+func (m *Model) SetAuto(view *config.View, reason string) {
+	m.auto = view
+	m.autoReason = reason
+	for i, item := range m.viewList.Items() {
+		if item == view {
+			m.viewList.Select(i)
+		}
+	}
+}
+
 func (m *Model) View() string {
 	if !m.Visible {
 		return ""
 	}
-	return footerBorder.Width(m.common.Width).Render(m.viewList.View()) + "\n"
+	view := footerBorder.Width(m.common.Width).Render(m.viewList.View()) + "\n"
+	switch {
+	case m.action == actionDelete:
+		selected := m.viewList.SelectedItem().(*config.View)
+		view += fmt.Sprintf("delete view %s? (y/n)", selected.Name) + "\n"
+	case m.action != actionNone:
+		view += m.input.View() + "\n"
+	}
+	if m.err != "" {
+		view += config.ListStyle.Render(m.err) + "\n"
+	}
+	return view
 }
 
 func toListItem(items []config.View) []list.Item {
@@ -80,7 +297,13 @@ func toListItem(items []config.View) []list.Item {
 
 func New(c *common.Common) *Model {
 	items := c.Cfg.Views
-	l := list.New(toListItem(items), listDelegate{}, 0, 0)
+	m := &Model{
+		common: c,
+		Height: 7,
+		input:  textinput.New(),
+	}
+	m.input.Prompt = "name: "
+	l := list.New(toListItem(items), listDelegate{model: m}, 0, 0)
 	l.Styles.Title = lipgloss.NewStyle().Foreground(lipgloss.Color("#00FFFF")).Bold(true)
 	l.Styles.TitleBar = lipgloss.NewStyle().Background(lipgloss.Color(""))
 	l.Title = config.TitleStyle.Render("Views")
@@ -89,17 +312,14 @@ func New(c *common.Common) *Model {
 	l.SetFilteringEnabled(false)
 	l.DisableQuitKeybindings()
 	l.SetShowPagination(false)
-	m := &Model{
-		common:   c,
-		viewList: l,
-		Height:   7,
-	}
+	m.viewList = l
 	m.viewList.SetHeight(m.Height)
 	c.AddWindowResizeEventListener(m)
 	return m
 }
 
 type listDelegate struct {
+	model *Model
 }
 
 func (d listDelegate) Height() int { return 1 }
@@ -126,9 +346,14 @@ func (d listDelegate) Render(w io.Writer, m list.Model, index int, item list.Ite
 		style = config.ListStyle
 	}
 
+	var auto string
+	if listItem == d.model.auto {
+		auto = config.ListStyle.Render("  auto: " + d.model.autoReason)
+	}
+
 	if isSelected {
-		fmt.Fprint(w, style.Render("● "+listItem.Name))
+		fmt.Fprint(w, style.Render("● "+listItem.Name)+auto)
 	} else {
-		fmt.Fprint(w, style.Render("• "+listItem.Name))
+		fmt.Fprint(w, style.Render("• "+listItem.Name)+auto)
 	}
 }
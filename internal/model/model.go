@@ -8,8 +8,10 @@ import (
 	"github.com/filipecaixeta/logviewer/internal/common"
 	"github.com/filipecaixeta/logviewer/internal/config"
 	"github.com/filipecaixeta/logviewer/internal/logs"
+	"github.com/filipecaixeta/logviewer/internal/source"
 	"github.com/filipecaixeta/logviewer/internal/source/docker"
 	"github.com/filipecaixeta/logviewer/internal/source/fake"
+	"github.com/filipecaixeta/logviewer/internal/source/file"
 	"github.com/filipecaixeta/logviewer/internal/source/k8s"
 	"github.com/filipecaixeta/logviewer/internal/source/stdin"
 	"github.com/filipecaixeta/logviewer/internal/state"
@@ -50,11 +52,13 @@ func New(ctx context.Context, cfg *config.Config) *Model {
 	if cfg.Command == "k8s" {
 		c.Src = k8s.New(cfg)
 	} else if cfg.Command == "docker" {
-		c.Src = docker.New()
+		c.Src = docker.New(cfg)
 	} else if cfg.Command == "test" {
 		c.Src = fake.New(cfg)
 	} else if cfg.Command == "stdin" {
 		c.Src = stdin.New(cfg)
+	} else if cfg.Command == "file" {
+		c.Src = file.New(cfg)
 	}
 	b := browse.New(c)
 	c.AddWindowResizeEventListener(b)
@@ -82,6 +86,13 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	// fmt.Printf("State: %d, Update %T %v\n", m.common.State, msg, msg)
 	switch msg := msg.(type) {
 	case error:
+		// the errors of the logs stream are shown in the logs view
+		if m.logs != nil && (m.common.State == state.StateLogs || m.common.State == state.StateLogsLoading) {
+			m.common.PrevState = m.common.State
+			m.common.State = state.StateLogs
+			_, cmd := m.logs.Update(msg)
+			return m, cmd
+		}
 		m.Err = msg
 		return m, tea.Quit
 	case tea.KeyMsg:
@@ -91,6 +102,26 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 	case tea.WindowSizeMsg:
 		return m, m.common.SetSize(msg)
+	case source.ColumnsMsg:
+		msg.Update()
+		return m, msg.Next
+	case source.ErrorMsg:
+		_, cmd := m.browse.Update(msg)
+		return m, cmd
+	case source.ContextMsg:
+		// the errors of the context are shown in the browse view
+		if msg.Update != nil {
+			msg.Update()
+		}
+		_, cmd := m.browse.Update(msg)
+		return m, cmd
+	case logs.StreamEndedMsg:
+		// the stream may end before the logs are displayed
+		if m.logs != nil {
+			_, cmd := m.logs.Update(msg)
+			return m, cmd
+		}
+		return m, nil
 	case state.State:
 		if msg == state.StateLoading && m.common.PrevState != state.StateLoading {
 			return m, tea.Batch(m.common.HandleStateChange(), m.loadingSpinner.Tick)
@@ -104,12 +135,19 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.common.AddWindowResizeEventListener(m.logs)
 			return m, tea.Batch(m.common.HandleStateChange(), m.logs.Init(), m.loadingSpinner.Tick)
 		} else if msg == state.StateLogs {
-			_, cmd := m.logs.Update(logs.LogMsg(""))
+			// a reload of the logs already reads the new stream
+			if m.common.PrevState != state.StateLogsLoading {
+				return m, m.common.HandleStateChange()
+			}
+			_, cmd := m.logs.Update(logs.LogMsg{})
 			return m, tea.Batch(m.common.HandleStateChange(), cmd)
 		} else if msg == state.StateBrose && m.common.PrevState == state.StateLogs {
-			m.logs.Close()
+			// stdin can't be read again, so its stream is kept open
+			if _, ok := m.common.Src.(*stdin.Stdin); !ok {
+				m.logs.Close()
+			}
 			return m, m.common.HandleStateChange()
-		} else if msg != state.StateNewView && msg != state.StateLoadView {
+		} else if msg != state.StateLoadView {
 			return m, m.common.HandleStateChange()
 		}
 	case spinner.TickMsg:
@@ -127,7 +165,7 @@ func (m *Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	case state.StateBrose:
 		_, cmd := m.browse.Update(msg)
 		return m, cmd
-	case state.StateLogs, state.StateNewView, state.StateLoadView:
+	case state.StateLogs, state.StateLoadView:
 		_, cmd := m.logs.Update(msg)
 		return m, cmd
 	}
@@ -0,0 +1,170 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/docker/docker/client"
+)
+
+// newClient creates the client of the daemon at host, like
+// unix:///var/run/docker.sock, tcp://host:2376 or ssh://user@host.
+// When host is empty DOCKER_HOST is used, and when it isn't set either
+// the Docker socket, or the Podman socket if there is no Docker socket.
+func newClient(host string) (*client.Client, error) {
+	opts := []client.Opt{client.FromEnv, client.WithAPIVersionNegotiation()}
+
+	if host == "" && os.Getenv(client.EnvOverrideHost) == "" {
+		host = podmanHost()
+	}
+	if strings.HasPrefix(host, "ssh://") {
+		u, err := url.Parse(host)
+		if err != nil {
+			return nil, err
+		}
+		// the host is only used to build the URLs of the requests,
+		// the connections are made by the dialer
+		opts = append(opts, client.WithHost("http://docker.example.com"), client.WithDialContext(sshDialer(u)))
+	} else if host != "" {
+		opts = append(opts, client.WithHost(host))
+	}
+
+	return client.NewClientWithOpts(opts...)
+}
+
+// podmanHost returns the Docker-compatible socket of Podman, rootless or
+// rootful, when there is no Docker socket. It's empty if there is none.
+func podmanHost() string {
+	if _, err := os.Stat(strings.TrimPrefix(client.DefaultDockerHost, "unix://")); err == nil {
+		return ""
+	}
+	var sockets []string
+	if dir := os.Getenv("XDG_RUNTIME_DIR"); dir != "" {
+		sockets = append(sockets, filepath.Join(dir, "podman", "podman.sock"))
+	}
+	sockets = append(sockets, "/run/podman/podman.sock")
+	for _, socket := range sockets {
+		if _, err := os.Stat(socket); err == nil {
+			return "unix://" + socket
+		}
+	}
+	return ""
+}
+
+// sshDialer connects to the daemon through ssh, like the docker CLI does,
+// running `docker system dial-stdio` on the remote host
+func sshDialer(u *url.URL) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		var args []string
+		if u.User != nil {
+			args = append(args, "-l", u.User.Username())
+		}
+		if port := u.Port(); port != "" {
+			args = append(args, "-p", port)
+		}
+		args = append(args, "--", u.Hostname(), "docker", "system", "dial-stdio")
+		return newCommandConn(exec.CommandContext(ctx, "ssh", args...))
+	}
+}
+
+// commandConn is a connection to the stdin and stdout of a command
+type commandConn struct {
+	cmd       *exec.Cmd
+	stdin     io.WriteCloser
+	stdout    io.ReadCloser
+	stderr    *lockedBuffer
+	closeOnce sync.Once
+}
+
+func newCommandConn(cmd *exec.Cmd) (*commandConn, error) {
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	stderr := &lockedBuffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	return &commandConn{cmd: cmd, stdin: stdin, stdout: stdout, stderr: stderr}, nil
+}
+
+// Read returns what the command wrote to stderr when it exits,
+// so the ssh errors are not reported as an unexpected EOF
+func (c *commandConn) Read(p []byte) (int, error) {
+	n, err := c.stdout.Read(p)
+	if errors.Is(err, io.EOF) {
+		if msg := strings.TrimSpace(c.stderr.String()); msg != "" {
+			return n, fmt.Errorf("%s: %s", c.cmd.Path, msg)
+		}
+	}
+	return n, err
+}
+
+func (c *commandConn) Write(p []byte) (int, error) {
+	return c.stdin.Write(p)
+}
+
+func (c *commandConn) Close() error {
+	c.closeOnce.Do(func() {
+		c.stdin.Close()
+		c.stdout.Close()
+		_ = c.cmd.Process.Kill()
+		_ = c.cmd.Wait()
+	})
+	return nil
+}
+
+func (c *commandConn) LocalAddr() net.Addr {
+	return &net.UnixAddr{Name: "local", Net: "unix"}
+}
+
+func (c *commandConn) RemoteAddr() net.Addr {
+	return &net.UnixAddr{Name: "remote", Net: "unix"}
+}
+
+func (c *commandConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *commandConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *commandConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+// lockedBuffer is a buffer that can be written by the command
+// while it's read by the connection
+type lockedBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *lockedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *lockedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
@@ -2,6 +2,7 @@ package docker
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/filipecaixeta/logviewer/internal/source"
 
@@ -10,24 +11,31 @@ import (
 
 // ContainerItem represents a Docker container in the list.
 type ContainerItem struct {
-	ID     string
-	Name   string
-	Image  string
-	Status string
+	ID      string
+	Name    string
+	Image   string
+	Status  string
+	State   string
+	Project string
 }
 
 // NewContainerItem creates a new instance of ContainerItem from a Docker API Container type.
 func NewContainerItem(container types.Container) *ContainerItem {
 	return &ContainerItem{
-		ID:     container.ID,
-		Name:   getContainerName(container.Names),
-		Image:  container.Image,
-		Status: container.Status,
+		ID:      container.ID,
+		Name:    getContainerName(container.Names),
+		Image:   container.Image,
+		Status:  container.Status,
+		State:   container.State,
+		Project: container.Labels[composeProjectLabel],
 	}
 }
 
 // String returns a string representation of the container, which will be displayed in the list.
 func (c *ContainerItem) String() string {
+	if c.State != "running" {
+		return fmt.Sprintf("%s | %s (%s)", c.Name, c.Image, c.State)
+	}
 	return fmt.Sprintf("%s | %s", c.Name, c.Image)
 }
 
@@ -48,3 +56,59 @@ func getContainerName(names []string) string {
 	}
 	return "Unknown"
 }
+
+// composeProjectLabel is the label Docker Compose sets on the containers of a project
+const composeProjectLabel = "com.docker.compose.project"
+
+// ProjectItem groups the containers of a Docker Compose project.
+// The containers that don't belong to a project are grouped in a
+// project without name.
+type ProjectItem struct {
+	Name       string
+	Containers []*ContainerItem
+}
+
+func (p *ProjectItem) String() string {
+	if p.Name == "" {
+		return "Standalone containers"
+	}
+	return p.Name
+}
+
+func (p *ProjectItem) Children() []source.ListItem {
+	return source.ConvertInterface2ListItems(p.Containers)
+}
+
+func (p *ProjectItem) FilterValue() string {
+	return p.Name
+}
+
+// groupByProject returns the projects of the containers sorted by name, with
+// the standalone containers last
+func groupByProject(containers []types.Container) []*ProjectItem {
+	byName := map[string]*ProjectItem{}
+	for _, container := range containers {
+		c := NewContainerItem(container)
+		p, ok := byName[c.Project]
+		if !ok {
+			p = &ProjectItem{Name: c.Project}
+			byName[c.Project] = p
+		}
+		p.Containers = append(p.Containers, c)
+	}
+
+	projects := make([]*ProjectItem, 0, len(byName))
+	for _, p := range byName {
+		sort.Slice(p.Containers, func(i, j int) bool {
+			return p.Containers[i].Name < p.Containers[j].Name
+		})
+		projects = append(projects, p)
+	}
+	sort.Slice(projects, func(i, j int) bool {
+		if (projects[i].Name == "") != (projects[j].Name == "") {
+			return projects[j].Name == ""
+		}
+		return projects[i].Name < projects[j].Name
+	})
+	return projects
+}
@@ -0,0 +1,108 @@
+package docker
+
+import (
+	"context"
+	"time"
+
+	"github.com/filipecaixeta/logviewer/internal/source"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/docker/docker/api/types"
+	"github.com/docker/docker/api/types/filters"
+)
+
+// containerEvents are the events that change the list of containers
+var containerEvents = []string{"create", "start", "die", "destroy", "rename", "pause", "unpause"}
+
+func (d *DockerSource) listContainers() ([]types.Container, error) {
+	return d.dockerCli.ContainerList(context.Background(), types.ContainerListOptions{All: d.all.Load()})
+}
+
+// setContainers groups the containers by project, keeping the
+// selected project and container if they still exist
+func (d *DockerSource) setContainers(containers []types.Container) {
+	var selectedProject, selectedContainer string
+	if p, ok := d.columns[0].SelectedItem().(*ProjectItem); ok {
+		selectedProject = p.Name
+	}
+	if c, ok := d.columns[1].SelectedItem().(*ContainerItem); ok {
+		selectedContainer = c.ID
+	}
+
+	projects := groupByProject(containers)
+	d.columns[0].SetItems(source.ConvertInterface2ListItems(projects))
+	d.columns[0].Select(0)
+	d.columns[1].SetItems([]source.ListItem{})
+	for i, p := range projects {
+		if p.Name != selectedProject {
+			continue
+		}
+		d.columns[0].Select(i)
+		d.columns[1].SetItems(p.Children())
+		d.columns[1].Select(0)
+		for j, c := range p.Containers {
+			if c.ID == selectedContainer {
+				d.columns[1].Select(j)
+			}
+		}
+		return
+	}
+	if len(projects) > 0 {
+		d.columns[1].SetItems(projects[0].Children())
+		d.columns[1].Select(0)
+	}
+}
+
+// changed asks for the containers to be listed again, the
+// changes that happen before they are listed are merged
+func (d *DockerSource) changed() {
+	select {
+	case d.changes <- struct{}{}:
+	default:
+	}
+}
+
+// waitForChange lists the containers after a change and
+// returns the message that updates the columns
+func (d *DockerSource) waitForChange() tea.Msg {
+	for range d.changes {
+		containers, err := d.listContainers()
+		if err != nil {
+			continue
+		}
+		return source.ColumnsMsg{
+			Update: func() { d.setContainers(containers) },
+			Next:   d.waitForChange,
+		}
+	}
+	return nil
+}
+
+// watchEvents watches the container events of the Docker daemon,
+// watching again after a while if the connection is lost
+func (d *DockerSource) watchEvents(ctx context.Context) {
+	args := filters.NewArgs(filters.Arg("type", "container"))
+	for _, event := range containerEvents {
+		args.Add("event", event)
+	}
+
+	for ctx.Err() == nil {
+		messages, errs := d.dockerCli.Events(ctx, types.EventsOptions{Filters: args})
+	loop:
+		for {
+			select {
+			case <-messages:
+				d.changed()
+			case <-errs:
+				break loop
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+		case <-time.After(5 * time.Second):
+			// the containers may have changed while disconnected
+			d.changed()
+		}
+	}
+}
@@ -1,100 +1,322 @@
 package docker
 
 import (
-	"bufio"
 	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/filipecaixeta/logviewer/internal/config"
 	"github.com/filipecaixeta/logviewer/internal/source"
 	"github.com/filipecaixeta/logviewer/internal/state"
 
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/docker/docker/api/types"
 	"github.com/docker/docker/client"
+	"github.com/docker/docker/pkg/stdcopy"
 )
 
+// pingTimeout is the time to wait for the daemon to answer at start
+const pingTimeout = 10 * time.Second
+
 type DockerSource struct {
 	columns   []*source.List
 	dockerCli *client.Client
+	// host is the daemon host shown in the errors
+	host string
+	// err is the error creating the client, reported by Init
+	err error
+	// all lists the stopped containers too
+	all atomic.Bool
+	// changes is signaled when the containers must be listed again
+	changes chan struct{}
 }
 
-func New() source.Source {
-	cli, err := client.NewClientWithOpts(client.FromEnv, client.WithAPIVersionNegotiation())
-	if err != nil {
-		panic(err)
+func New(cfg *config.Config) source.Source {
+	cli, err := newClient(cfg.DockerHost)
+	host := cfg.DockerHost
+	if cli != nil && !strings.HasPrefix(host, "ssh://") {
+		host = cli.DaemonHost()
 	}
 
 	return &DockerSource{
 		columns: []*source.List{
+			source.NewList("Projects", []source.ListItem{}),
 			source.NewList("Containers", []source.ListItem{}),
 		},
 		dockerCli: cli,
+		host:      host,
+		err:       err,
+		changes:   make(chan struct{}, 1),
 	}
 }
 
 func (d *DockerSource) Init(stateChan chan state.State) tea.Cmd {
-	return func() tea.Msg {
-		d.refreshContainers()
+	// the errors are shown in the browse view, with no containers
+	fail := func(err error) tea.Msg {
+		stateChan <- state.StateBrose
+		return source.ErrorMsg{Err: err}
+	}
+	load := func() tea.Msg {
+		stateChan <- state.StateLoading
+		if d.err != nil {
+			return fail(fmt.Errorf("can't create the Docker client: %w", d.err))
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+		defer cancel()
+		if _, err := d.dockerCli.Ping(ctx); err != nil {
+			return fail(connectError(d.host, err))
+		}
+
+		containers, err := d.listContainers()
+		if err != nil {
+			return fail(err)
+		}
+		d.setContainers(containers)
+		go d.watchEvents(context.Background())
 		stateChan <- state.StateBrose
 		return nil
 	}
+	return tea.Batch(load, d.waitForChange)
 }
 
 func (d *DockerSource) Columns() []*source.List {
 	return d.columns
 }
 
-func (d *DockerSource) Logs(ctx context.Context, stateChan chan state.State, logChan chan string) tea.Cmd {
-	return func() tea.Msg {
-		selectedItem := d.columns[0].SelectedItem()
-		if selectedItem == nil {
-			return nil // Or handle the no selection case
+func (d *DockerSource) ShowAll() bool {
+	return d.all.Load()
+}
+
+// SetShowAll shows or hides the stopped containers, the
+// columns are updated by the next ColumnsMsg
+func (d *DockerSource) SetShowAll(all bool) {
+	d.all.Store(all)
+	d.changed()
+}
+
+// Logs streams the logs of the selected container, or of all the
+// containers of the project when the projects column is active
+func (d *DockerSource) Logs(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return d.Target()(ctx, opts, stateChan, logChan)
+}
+
+// Target returns the stream of the containers selected now, the events
+// refreshing the columns can select another container in the meantime
+func (d *DockerSource) Target() source.Stream {
+	var containers []*ContainerItem
+	if d.columns[0].IsActive() {
+		if project, ok := d.columns[0].SelectedItem().(*ProjectItem); ok {
+			containers = project.Containers
 		}
+	} else if container, ok := d.columns[1].SelectedItem().(*ContainerItem); ok {
+		containers = []*ContainerItem{container}
+	}
+	return func(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+		return d.logs(ctx, containers, opts, stateChan, logChan)
+	}
+}
 
-		container, ok := selectedItem.(*ContainerItem)
-		if !ok {
-			return nil // Or handle the type assertion error
+func (d *DockerSource) logs(ctx context.Context, containers []*ContainerItem, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return func() tea.Msg {
+		if len(containers) == 0 {
+			stateChan <- state.StateBrose
+			return nil
 		}
 
-		containerID := container.ID
+		stateChan <- state.StateLogs
 
-		options := types.ContainerLogsOptions{ShowStdout: true, ShowStderr: true, Follow: true}
-		logsReader, err := d.dockerCli.ContainerLogs(ctx, containerID, options)
-		if err != nil {
-			// Handle error
-			return err
+		options := containerLogsOptions(opts, len(containers), time.Now())
+		errs := make([]error, len(containers))
+		var wg sync.WaitGroup
+		for i, container := range containers {
+			wg.Add(1)
+			go func(i int, container *ContainerItem) {
+				defer wg.Done()
+				if options.Follow {
+					errs[i] = d.followLogs(ctx, container, options, opts.Until, logChan)
+				} else {
+					errs[i] = d.streamLogs(ctx, container, options, nil, nil, logChan)
+				}
+			}(i, container)
 		}
-		defer logsReader.Close()
+		wg.Wait()
 
-		scanner := bufio.NewScanner(logsReader)
-		stateChan <- state.StateLogs
-		for scanner.Scan() {
-			t := scanner.Text()
-			// remove log line header
-			if len(t) > 8 {
-				logChan <- t[8:]
+		if ctx.Err() != nil {
+			return nil
+		}
+		// the stream of a container that was removed fails,
+		// it's only an error when no container could be streamed
+		for _, err := range errs {
+			if err == nil {
+				return nil
 			}
 		}
+		return errs[0]
+	}
+}
+
+// containerLogsOptions returns the options of the streams of n containers starting
+// at now. By default the number of lines loaded at start is the same no matter
+// how many containers are streamed.
+func containerLogsOptions(opts source.LogOptions, n int, now time.Time) types.ContainerLogsOptions {
+	options := types.ContainerLogsOptions{
+		ShowStdout: true,
+		ShowStderr: true,
+		Follow:     opts.Follow(now),
+	}
+	switch {
+	case opts.Tail > 0:
+		options.Tail = strconv.Itoa(opts.Tail)
+	case opts.Tail == 0:
+		options.Tail = strconv.Itoa(max(10000/max(n, 1), 100))
+	}
+	if start := opts.Start(now); !start.IsZero() {
+		options.Since = timestamp(start)
+	}
+	if !opts.Until.IsZero() {
+		options.Until = timestamp(opts.Until)
+	}
+	return options
+}
 
-		if err := scanner.Err(); err != nil {
-			// Handle scanning error
-			return err
+// timestamp formats a time as the seconds since the epoch with
+// nanoseconds, the format of the times of the Docker API
+func timestamp(t time.Time) string {
+	return fmt.Sprintf("%d.%09d", t.Unix(), t.Nanosecond())
+}
+
+// Meta returns the metadata of the selected container,
+// or only the project when the projects column is active
+func (d *DockerSource) Meta() map[string]string {
+	if d.columns[0].IsActive() {
+		if project, ok := d.columns[0].SelectedItem().(*ProjectItem); ok && project.Name != "" {
+			return map[string]string{"project": project.Name}
 		}
+		return map[string]string{}
+	}
+	if container, ok := d.columns[1].SelectedItem().(*ContainerItem); ok {
+		return containerMeta(container)
+	}
+	return map[string]string{}
+}
 
-		return nil
+func containerMeta(container *ContainerItem) map[string]string {
+	meta := map[string]string{
+		"container":   container.Name,
+		"containerId": container.ID,
+		"image":       container.Image,
+	}
+	if container.Project != "" {
+		meta["project"] = container.Project
+	}
+	return meta
+}
+
+// followLogs streams the logs of a container until it stops, reconnecting
+// when the stream drops. The reconnected stream starts from the time of
+// the last line received.
+func (d *DockerSource) followLogs(ctx context.Context, container *ContainerItem, options types.ContainerLogsOptions, until time.Time, logChan chan source.LogLine) error {
+	options.Timestamps = true
+	resume := source.NewResume(time.Now())
+	connect := func(ctx context.Context, reconnect bool, connected func()) error {
+		opts := options
+		if reconnect {
+			opts.Tail = ""
+			opts.Since = timestamp(resume.Since())
+		}
+		err := d.streamLogs(ctx, container, opts, resume, connected, logChan)
+		// the daemon ends the stream at until
+		if err == nil && !until.IsZero() && !time.Now().Before(until) {
+			return source.ErrUntil
+		}
+		return err
+	}
+	running := func(ctx context.Context) (bool, error) {
+		inspect, err := d.dockerCli.ContainerInspect(ctx, container.ID)
+		if client.IsErrNotFound(err) {
+			return false, nil
+		}
+		if err != nil {
+			return false, err
+		}
+		return inspect.State != nil && inspect.State.Running, nil
 	}
+	return source.Follow(ctx, logChan, containerMeta(container), connect, running)
 }
 
-func (d *DockerSource) refreshContainers() {
-	containers, err := d.dockerCli.ContainerList(context.Background(), types.ContainerListOptions{})
+// streamLogs sends the logs of a container to logChan until the stream ends.
+// When the timestamps are requested resume skips the lines already received,
+// if not nil. connected is called once the stream is open, if not nil.
+func (d *DockerSource) streamLogs(ctx context.Context, container *ContainerItem, options types.ContainerLogsOptions, resume *source.Resume, connected func(), logChan chan source.LogLine) error {
+	containerID := container.ID
+	meta := containerMeta(container)
+
+	inspect, err := d.dockerCli.ContainerInspect(ctx, containerID)
+	if err != nil {
+		return err
+	}
+	tty := inspect.Config != nil && inspect.Config.Tty
+
+	logsReader, err := d.dockerCli.ContainerLogs(ctx, containerID, options)
+	if err != nil {
+		return err
+	}
+	defer logsReader.Close()
+	if connected != nil {
+		connected()
+	}
+
+	// a container with a TTY has a single raw stream, stdout and stderr
+	// are only multiplexed with a header per frame without one
+	var writers []*lineWriter
+	if tty {
+		w := newLineWriter(ctx, logChan, meta, options.Timestamps, resume)
+		writers = append(writers, w)
+		_, err = io.Copy(w, logsReader)
+	} else {
+		stdout := newLineWriter(ctx, logChan, withStream(meta, "stdout"), options.Timestamps, resume)
+		stderr := newLineWriter(ctx, logChan, withStream(meta, "stderr"), options.Timestamps, resume)
+		writers = append(writers, stdout, stderr)
+		_, err = stdcopy.StdCopy(stdout, stderr, logsReader)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
 	if err != nil {
-		// Handle error
-		return
+		return err
+	}
+	// flushing only fails when the context is cancelled
+	for _, w := range writers {
+		_ = w.Flush()
 	}
 
-	containerItems := []source.ListItem{}
-	for _, container := range containers {
-		containerItems = append(containerItems, NewContainerItem(container))
+	return nil
+}
+
+// connectError describes why the daemon at host can't be reached
+func connectError(host string, err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		err = urlErr.Err
+	} else if client.IsErrConnectionFailed(err) {
+		return fmt.Errorf("can't connect to the Docker daemon at %s, is it running?", host)
 	}
+	return fmt.Errorf("can't connect to the Docker daemon at %s: %w", host, err)
+}
 
-	d.columns[0].SetItems(containerItems)
+// withStream returns a copy of meta with the stream of the lines
+func withStream(meta map[string]string, stream string) map[string]string {
+	m := make(map[string]string, len(meta)+1)
+	for k, v := range meta {
+		m[k] = v
+	}
+	m["stream"] = stream
+	return m
 }
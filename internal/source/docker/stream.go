@@ -0,0 +1,81 @@
+package docker
+
+import (
+	"bytes"
+	"context"
+	"time"
+
+	"github.com/filipecaixeta/logviewer/internal/source"
+)
+
+// maxLineSize is the size at which a line without a line break is sent
+const maxLineSize = 1024 * 1024
+
+// lineWriter splits the output of a stream in lines and sends them to logChan.
+// A frame of the multiplexed stream can hold several lines and a line can span
+// several frames, so the end of the last line is kept until the next write.
+// When the lines start with a timestamp it's removed, and resume skips the
+// lines already received if not nil.
+type lineWriter struct {
+	ctx        context.Context
+	logChan    chan source.LogLine
+	meta       map[string]string
+	timestamps bool
+	resume     *source.Resume
+	buf        []byte
+}
+
+func newLineWriter(ctx context.Context, logChan chan source.LogLine, meta map[string]string, timestamps bool, resume *source.Resume) *lineWriter {
+	return &lineWriter{ctx: ctx, logChan: logChan, meta: meta, timestamps: timestamps, resume: resume}
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf = append(w.buf, p...)
+	start := 0
+	for {
+		i := bytes.IndexByte(w.buf[start:], '\n')
+		if i < 0 {
+			break
+		}
+		if err := w.send(w.buf[start : start+i]); err != nil {
+			return 0, err
+		}
+		start += i + 1
+	}
+	w.buf = append(w.buf[:0], w.buf[start:]...)
+
+	if len(w.buf) >= maxLineSize {
+		if err := w.Flush(); err != nil {
+			return 0, err
+		}
+	}
+	return len(p), nil
+}
+
+// Flush sends the last line when the stream didn't end with a line break
+func (w *lineWriter) Flush() error {
+	if len(w.buf) == 0 {
+		return nil
+	}
+	err := w.send(w.buf)
+	w.buf = w.buf[:0]
+	return err
+}
+
+func (w *lineWriter) send(line []byte) error {
+	// containers with a TTY end the lines with \r\n
+	text := string(bytes.TrimSuffix(line, []byte{'\r'}))
+	if w.timestamps {
+		var ts time.Time
+		ts, text = source.SplitTimestamp(text)
+		if w.resume != nil && w.resume.Skip(ts) {
+			return nil
+		}
+	}
+	select {
+	case <-w.ctx.Done():
+		return w.ctx.Err()
+	case w.logChan <- source.LogLine{Text: text, Meta: w.meta}:
+		return nil
+	}
+}
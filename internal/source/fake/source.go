@@ -86,8 +86,30 @@ func (f *Fake) getLogCfg() map[string]string {
 	return cfg
 }
 
-func (f *Fake) Logs(ctx context.Context, stateChan chan state.State, logChan chan string) tea.Cmd {
+// Meta returns the selected items, the columns are
+// named in plural, the metadata in singular
+func (f *Fake) Meta() map[string]string {
+	meta := map[string]string{}
+	for k, v := range f.getLogCfg() {
+		meta[strings.TrimSuffix(k, "s")] = v
+	}
+	return meta
+}
+
+func (f *Fake) Logs(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return f.Target()(ctx, opts, stateChan, logChan)
+}
+
+// Target returns the stream of the items selected now
+func (f *Fake) Target() source.Stream {
 	cfg := f.getLogCfg()
+	meta := f.Meta()
+	return func(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+		return f.logs(ctx, cfg, meta, stateChan, logChan)
+	}
+}
+
+func (f *Fake) logs(ctx context.Context, cfg, meta map[string]string, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
 	return func() tea.Msg {
 		stateChan <- state.StateLogs
 
@@ -108,7 +130,7 @@ func (f *Fake) Logs(ctx context.Context, stateChan chan state.State, logChan cha
 			select {
 			case <-ctx.Done():
 				return nil
-			case logChan <- l:
+			case logChan <- source.LogLine{Text: l, Meta: meta}:
 			}
 		}
 
@@ -129,7 +151,7 @@ func (f *Fake) Logs(ctx context.Context, stateChan chan state.State, logChan cha
 			select {
 			case <-ctx.Done():
 				return nil
-			case logChan <- string(jsonLog):
+			case logChan <- source.LogLine{Text: string(jsonLog), Meta: meta}:
 				time.Sleep(100 * time.Millisecond)
 			}
 		}
@@ -0,0 +1,95 @@
+package file
+
+import (
+	"context"
+	"path/filepath"
+
+	"github.com/filipecaixeta/logviewer/internal/config"
+	"github.com/filipecaixeta/logviewer/internal/source"
+	"github.com/filipecaixeta/logviewer/internal/state"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// FileItem represents a log file in the list.
+type FileItem struct {
+	Path string
+}
+
+// String returns a string representation of the file, which will be displayed in the list.
+func (f *FileItem) String() string {
+	return f.Path
+}
+
+// Children returns an empty slice, as files don't have a hierarchical structure in this context.
+func (f *FileItem) Children() []source.ListItem {
+	return []source.ListItem{}
+}
+
+// FilterValue returns the value used for filtering the list.
+func (f *FileItem) FilterValue() string {
+	return filepath.Base(f.Path)
+}
+
+type File struct {
+	columns []*source.List
+}
+
+func New(config *config.Config) source.Source {
+	items := make([]*FileItem, len(config.Files))
+	for i, path := range config.Files {
+		items[i] = &FileItem{Path: path}
+	}
+
+	return &File{
+		columns: []*source.List{
+			source.NewList("Files", source.Convert2ListItems(items)),
+		},
+	}
+}
+
+func (f *File) Init(stateChan chan state.State) tea.Cmd {
+	return func() tea.Msg {
+		stateChan <- state.StateBrose
+		return nil
+	}
+}
+
+func (f *File) Columns() []*source.List {
+	return f.columns
+}
+
+// Meta returns the path of the selected file
+func (f *File) Meta() map[string]string {
+	if item, ok := f.columns[0].SelectedItem().(*FileItem); ok {
+		return map[string]string{"file": item.Path}
+	}
+	return map[string]string{}
+}
+
+func (f *File) Logs(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return f.Target()(ctx, opts, stateChan, logChan)
+}
+
+// Target returns the stream of the file selected now
+func (f *File) Target() source.Stream {
+	item, _ := f.columns[0].SelectedItem().(*FileItem)
+	return func(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+		return f.logs(ctx, item, opts, stateChan, logChan)
+	}
+}
+
+func (f *File) logs(ctx context.Context, item *FileItem, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return func() tea.Msg {
+		if item == nil {
+			stateChan <- state.StateBrose
+			return nil
+		}
+
+		stateChan <- state.StateLogs
+		return newTailer(item.Path, opts).follow(ctx, logChan)
+	}
+}
+
+func (f *File) Close() {
+}
@@ -0,0 +1,249 @@
+package file
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/filipecaixeta/logviewer/internal/source"
+)
+
+const (
+	pollInterval = 250 * time.Millisecond
+	// defaultTail is the number of lines read at start when no tail is
+	// given, like the lines loaded by the streams of the containers
+	defaultTail = 10000
+)
+
+// tailer follows a file by name like tail -F. It reopens the path when the
+// file is rotated (renamed and created again) and starts reading from the
+// beginning when the file is truncated in place (copytruncate).
+type tailer struct {
+	path    string
+	meta    map[string]string
+	file    *os.File
+	reader  *bufio.Reader
+	offset  int64
+	partial []byte
+
+	// tail is the number of lines read from the file at start, all if 0.
+	// since skips the file at start if it wasn't modified after it, as the
+	// lines have no time to compare with. The file stops being followed
+	// at until, the lines already in the file when it's opened are read.
+	tail  int
+	since time.Time
+	until time.Time
+}
+
+func newTailer(path string, opts source.LogOptions) *tailer {
+	tail := opts.Tail
+	switch {
+	case tail == 0:
+		tail = defaultTail
+	case tail < 0:
+		tail = 0
+	}
+	return &tailer{
+		path:  path,
+		meta:  map[string]string{"file": path},
+		tail:  tail,
+		since: opts.Start(time.Now()),
+		until: opts.Until,
+	}
+}
+
+func (t *tailer) open() error {
+	f, err := os.Open(t.path)
+	if err != nil {
+		return err
+	}
+	t.file = f
+	t.reader = bufio.NewReader(f)
+	t.offset = 0
+	t.partial = t.partial[:0]
+	return nil
+}
+
+// skip moves to where the file is read from when it's opened at start. The
+// files that are opened after a rotation are read from the beginning.
+func (t *tailer) skip() error {
+	fi, err := t.file.Stat()
+	if err != nil {
+		return err
+	}
+
+	var offset int64
+	switch {
+	case !t.since.IsZero() && fi.ModTime().Before(t.since):
+		offset = fi.Size()
+	case t.tail > 0:
+		offset, err = lastLinesOffset(t.file, fi.Size(), t.tail)
+		if err != nil {
+			return err
+		}
+	default:
+		return nil
+	}
+
+	if _, err := t.file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+	t.reader.Reset(t.file)
+	t.offset = offset
+	return nil
+}
+
+// lastLinesOffset returns the offset of the first of the last n lines of the file
+func lastLinesOffset(f *os.File, size int64, n int) (int64, error) {
+	const chunkSize = 64 * 1024
+	buf := make([]byte, chunkSize)
+	lines := 0
+	for end := size; end > 0; {
+		start := max(0, end-chunkSize)
+		b := buf[:end-start]
+		if _, err := f.ReadAt(b, start); err != nil && err != io.EOF {
+			return 0, err
+		}
+		for i := len(b) - 1; i >= 0; i-- {
+			// the line break at the end of the file ends the last line
+			if b[i] != '\n' || start+int64(i) == size-1 {
+				continue
+			}
+			lines++
+			if lines == n {
+				return start + int64(i) + 1, nil
+			}
+		}
+		end = start
+	}
+	return 0, nil
+}
+
+func (t *tailer) close() {
+	if t.file != nil {
+		t.file.Close()
+		t.file = nil
+	}
+}
+
+// readLines sends every complete line available in the file to logChan.
+// An incomplete last line is kept until the rest of it is written.
+// It returns false if the context was cancelled.
+func (t *tailer) readLines(ctx context.Context, logChan chan source.LogLine) (bool, error) {
+	for {
+		b, err := t.reader.ReadBytes('\n')
+		t.offset += int64(len(b))
+		t.partial = append(t.partial, b...)
+		if err == io.EOF {
+			return true, nil
+		}
+		if err != nil {
+			return true, err
+		}
+		if !t.send(ctx, logChan) {
+			return false, nil
+		}
+	}
+}
+
+// send sends the pending line to logChan
+func (t *tailer) send(ctx context.Context, logChan chan source.LogLine) bool {
+	line := strings.TrimRight(string(t.partial), "\r\n")
+	t.partial = t.partial[:0]
+	select {
+	case <-ctx.Done():
+		return false
+	case logChan <- source.LogLine{Text: line, Meta: t.meta}:
+		return true
+	}
+}
+
+// rotated reports whether the path now points to a different file.
+// While the path doesn't exist the old file is still followed, as the
+// new file may not have been created yet.
+func (t *tailer) rotated() bool {
+	fi, err := os.Stat(t.path)
+	if err != nil {
+		return false
+	}
+	current, err := t.file.Stat()
+	if err != nil {
+		return true
+	}
+	return !os.SameFile(fi, current)
+}
+
+// truncated reports whether the file is now smaller than what was read
+func (t *tailer) truncated() bool {
+	current, err := t.file.Stat()
+	if err != nil {
+		return false
+	}
+	return current.Size() < t.offset
+}
+
+// follow reads the file until the context is cancelled or until is reached
+func (t *tailer) follow(ctx context.Context, logChan chan source.LogLine) error {
+	defer t.close()
+
+	// a file created after the start only has new lines, so it's not skipped
+	if err := t.open(); err == nil {
+		if err := t.skip(); err != nil {
+			return err
+		}
+	}
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if t.file == nil {
+			// the file doesn't exist yet, try again on the next tick
+			_ = t.open()
+		}
+
+		if t.file != nil {
+			ok, err := t.readLines(ctx, logChan)
+			if !ok {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+
+			switch {
+			case t.rotated():
+				// drain what was written to the old file before switching
+				if ok, _ := t.readLines(ctx, logChan); !ok {
+					return nil
+				}
+				if len(t.partial) > 0 && !t.send(ctx, logChan) {
+					return nil
+				}
+				t.close()
+				continue
+			case t.truncated():
+				if _, err := t.file.Seek(0, io.SeekStart); err != nil {
+					return err
+				}
+				t.reader.Reset(t.file)
+				t.offset = 0
+				t.partial = t.partial[:0]
+				continue
+			}
+		}
+
+		if !t.until.IsZero() && !time.Now().Before(t.until) {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
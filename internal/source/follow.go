@@ -0,0 +1,163 @@
+package source
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// StreamStatus is a change of the state of one of the streams of a source,
+// sent in a LogLine. The lines of the logs have no status.
+type StreamStatus int
+
+const (
+	StreamNoStatus StreamStatus = iota
+	// StreamDropped is sent when a stream dropped and is being reconnected
+	StreamDropped
+	// StreamReconnected is sent with the marker of a stream that was reconnected
+	StreamReconnected
+	// StreamClosed is sent when a dropped stream is not reconnected
+	// because its container stopped in the meantime
+	StreamClosed
+)
+
+// ErrUntil is returned by the streams that reached LogOptions.Until
+var ErrUntil = errors.New("reached the end of the time window")
+
+const maxBackoff = 30 * time.Second
+
+// Backoff returns the time to wait before an attempt to reconnect,
+// attempt starts at 0
+func Backoff(attempt int) time.Duration {
+	return min(time.Second<<min(attempt, 5), maxBackoff)
+}
+
+// ConnectFunc opens a stream and sends its lines to the logs until it ends.
+// It calls connected once the stream is open. reconnect is true when the
+// stream dropped before, the lines already received must be skipped.
+type ConnectFunc func(ctx context.Context, reconnect bool, connected func()) error
+
+// Follow runs connect until the stream ends for good. When the stream ends and
+// running reports the container is still running, the stream dropped: connect
+// is called again with a backoff until the stream is back. The logs view is
+// told with status lines, and a marker tells how long the stream was lost.
+// The error of the first connection is returned, the later ones are retried.
+func Follow(ctx context.Context, logChan chan LogLine, meta map[string]string, connect ConnectFunc, running func(ctx context.Context) (bool, error)) error {
+	var lost time.Time
+	attempt := 0
+	wasConnected := false
+	connected := func() {
+		wasConnected = true
+		if lost.IsZero() {
+			return
+		}
+		text := fmt.Sprintf("stream reconnected after %s", time.Since(lost).Round(time.Second))
+		send(ctx, logChan, LogLine{Text: text, Meta: meta, Marker: true, Status: StreamReconnected})
+		lost = time.Time{}
+		attempt = 0
+	}
+
+	for {
+		err := connect(ctx, wasConnected, connected)
+		if ctx.Err() != nil || errors.Is(err, ErrUntil) {
+			return nil
+		}
+		if !wasConnected {
+			return err
+		}
+
+		// the state is unknown when running fails, like when the API is down,
+		// so the stream is reconnected
+		if isRunning, err := running(ctx); err == nil && !isRunning {
+			if !lost.IsZero() {
+				send(ctx, logChan, LogLine{Meta: meta, Status: StreamClosed})
+			}
+			return nil
+		}
+		if lost.IsZero() {
+			lost = time.Now()
+			send(ctx, logChan, LogLine{Meta: meta, Status: StreamDropped})
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(Backoff(attempt)):
+		}
+		attempt++
+	}
+}
+
+func send(ctx context.Context, logChan chan LogLine, l LogLine) {
+	select {
+	case <-ctx.Done():
+	case logChan <- l:
+	}
+}
+
+// Resume tracks the timestamp of the last line received by a stream, so the
+// stream reconnected from that time skips the lines already received. The
+// APIs may round the time down and several lines can have the same time.
+type Resume struct {
+	last time.Time
+	// seen is the number of lines received with the last timestamp
+	seen int
+	// skip is the number of lines with the last timestamp left to skip
+	skip     int
+	resuming bool
+}
+
+// NewResume creates a Resume for a stream opened at start, which is
+// used when the stream drops before receiving any line
+func NewResume(start time.Time) *Resume {
+	return &Resume{last: start}
+}
+
+// Since returns the time to reconnect from, the lines
+// received from then on are checked by Skip
+func (r *Resume) Since() time.Time {
+	r.skip = r.seen
+	r.resuming = true
+	return r.last
+}
+
+// Skip records the timestamp of a line and reports whether
+// the line was already received before reconnecting
+func (r *Resume) Skip(ts time.Time) bool {
+	if ts.IsZero() {
+		return false
+	}
+	if r.resuming {
+		if ts.Before(r.last) {
+			return true
+		}
+		if ts.Equal(r.last) && r.skip > 0 {
+			r.skip--
+			return true
+		}
+		r.resuming = false
+	}
+	if ts.Equal(r.last) {
+		r.seen++
+	} else {
+		r.last = ts
+		r.seen = 1
+	}
+	return false
+}
+
+// SplitTimestamp splits the RFC3339 timestamp the APIs add at the start
+// of the lines from the log. The time is zero if there is none.
+func SplitTimestamp(line string) (time.Time, string) {
+	prefix, text, ok := strings.Cut(line, " ")
+	if !ok {
+		prefix, text = line, ""
+	}
+	ts, err := time.Parse(time.RFC3339Nano, prefix)
+	if err != nil {
+		return time.Time{}, line
+	}
+	return ts, text
+}
@@ -1,11 +1,21 @@
 package k8s
 
-import "github.com/filipecaixeta/logviewer/internal/source"
+import (
+	"fmt"
+
+	"github.com/filipecaixeta/logviewer/internal/source"
+)
 
 type Container struct {
 	Name     string `json:"name"`
 	Image    string `json:"image"`
 	ImageTag string `json:"imageTag"`
+	// RestartCount, LastTerminationReason and LastExitCode describe the
+	// restarts of the container, the last termination is the one of the
+	// previous instance
+	RestartCount          int32  `json:"restartCount"`
+	LastTerminationReason string `json:"lastTerminationReason,omitempty"`
+	LastExitCode          int32  `json:"lastExitCode,omitempty"`
 }
 
 func (n *Container) Children() []source.ListItem {
@@ -13,7 +23,13 @@ func (n *Container) Children() []source.ListItem {
 }
 
 func (n *Container) String() string {
-	return n.Name
+	if n.RestartCount == 0 {
+		return n.Name
+	}
+	if n.LastTerminationReason == "" {
+		return fmt.Sprintf("%s ↻%d", n.Name, n.RestartCount)
+	}
+	return fmt.Sprintf("%s ↻%d %s (exit %d)", n.Name, n.RestartCount, n.LastTerminationReason, n.LastExitCode)
 }
 
 func (n *Container) FilterValue() string {
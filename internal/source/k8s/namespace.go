@@ -2,12 +2,17 @@ package k8s
 
 import (
 	"context"
+	"path"
+	"slices"
+	"strings"
 	"sync"
 
 	"github.com/filipecaixeta/logviewer/internal/source"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -29,18 +34,75 @@ func (n *Namespace) FilterValue() string {
 	return n.Name
 }
 
+// ListNamespaces returns the namespaces matching the patterns. Patterns are
+// namespace names or globs like "team-*", no patterns matches every namespace.
+// The globs are matched against the namespaces of the cluster, if listing them
+// is forbidden the namespace of the kubeconfig context is used instead.
+func ListNamespaces(clientset *kubernetes.Clientset, patterns []string, defaultNamespace string) ([]string, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"*"}
+	}
+
+	var names []string
+	var globs []string
+	for _, p := range patterns {
+		if strings.ContainsAny(p, "*?[") {
+			globs = append(globs, p)
+		} else {
+			names = append(names, p)
+		}
+	}
+	if len(globs) == 0 {
+		return names, nil
+	}
+
+	list, err := clientset.CoreV1().Namespaces().List(context.Background(), metav1.ListOptions{})
+	if apierrors.IsForbidden(err) {
+		if len(names) == 0 && matchGlobs(globs, defaultNamespace) {
+			names = append(names, defaultNamespace)
+		}
+		return names, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, ns := range list.Items {
+		if !slices.Contains(names, ns.Name) && matchGlobs(globs, ns.Name) {
+			names = append(names, ns.Name)
+		}
+	}
+	return names, nil
+}
+
+// matchGlobs reports whether the namespace matches one of the globs
+func matchGlobs(globs []string, namespace string) bool {
+	if namespace == "" {
+		return false
+	}
+	for _, g := range globs {
+		if ok, _ := path.Match(g, namespace); ok {
+			return true
+		}
+	}
+	return false
+}
+
 func NewNamespace(name string, clientset *kubernetes.Clientset) *Namespace {
 	var replicaSets *appsv1.ReplicaSetList
 	var namespacedPods *v1.PodList
 	var deployments *appsv1.DeploymentList
 	var statefulsets *appsv1.StatefulSetList
+	var daemonsets *appsv1.DaemonSetList
+	var jobs *batchv1.JobList
+	var cronjobs *batchv1.CronJobList
 	var wg sync.WaitGroup
 
 	var n = &Namespace{Name: name}
 
 	n.Workloads = make([]*Workload, 0)
 
-	wg.Add(4)
+	wg.Add(7)
 	go func() {
 		replicaSets, _ = clientset.AppsV1().ReplicaSets(n.Name).List(context.Background(), metav1.ListOptions{})
 		wg.Done()
@@ -57,33 +119,91 @@ func NewNamespace(name string, clientset *kubernetes.Clientset) *Namespace {
 		statefulsets, _ = clientset.AppsV1().StatefulSets(n.Name).List(context.Background(), metav1.ListOptions{})
 		wg.Done()
 	}()
+	go func() {
+		daemonsets, _ = clientset.AppsV1().DaemonSets(n.Name).List(context.Background(), metav1.ListOptions{})
+		wg.Done()
+	}()
+	go func() {
+		jobs, _ = clientset.BatchV1().Jobs(n.Name).List(context.Background(), metav1.ListOptions{})
+		wg.Done()
+	}()
+	go func() {
+		cronjobs, _ = clientset.BatchV1().CronJobs(n.Name).List(context.Background(), metav1.ListOptions{})
+		wg.Done()
+	}()
 	wg.Wait()
 
-	for _, deployment := range deployments.Items {
+	owners := NewOwnerGraph(replicaSets.Items, jobs.Items)
+	add := func(kind string, obj metav1.Object, selector *metav1.LabelSelector) {
 		workload := &Workload{
-			Kind:              "Deployment",
-			Name:              deployment.Name,
-			UID:               string(deployment.UID),
-			Labels:            deployment.Labels,
-			CreationTimestamp: deployment.CreationTimestamp.Time,
-			Namespace:         deployment.Namespace,
+			Kind:              kind,
+			Name:              obj.GetName(),
+			UID:               string(obj.GetUID()),
+			Labels:            obj.GetLabels(),
+			Selector:          labelSelector(selector),
+			CreationTimestamp: obj.GetCreationTimestamp().Time,
+			Namespace:         obj.GetNamespace(),
 		}
-		workload.Pods = GetPodsForWorkload(replicaSets.Items, &deployment, namespacedPods.Items, "Deployment")
+		workload.Pods = GetPodsForWorkload(owners, obj, namespacedPods.Items, kind)
 		n.Workloads = append(n.Workloads, workload)
 	}
 
-	for _, statefulset := range statefulsets.Items {
-		workload := &Workload{
-			Kind:              "StatefulSet",
-			Name:              statefulset.Name,
-			UID:               string(statefulset.UID),
-			Labels:            statefulset.Labels,
-			CreationTimestamp: statefulset.CreationTimestamp.Time,
-			Namespace:         statefulset.Namespace,
+	for i := range deployments.Items {
+		add("Deployment", &deployments.Items[i], deployments.Items[i].Spec.Selector)
+	}
+	for i := range statefulsets.Items {
+		add("StatefulSet", &statefulsets.Items[i], statefulsets.Items[i].Spec.Selector)
+	}
+	for i := range daemonsets.Items {
+		add("DaemonSet", &daemonsets.Items[i], daemonsets.Items[i].Spec.Selector)
+	}
+	// ReplicaSets and Jobs are only listed when they are not managed
+	// by a Deployment or a CronJob, those list their pods already
+	for i := range replicaSets.Items {
+		if !hasOwnerKind(replicaSets.Items[i].OwnerReferences, "Deployment") {
+			add("ReplicaSet", &replicaSets.Items[i], replicaSets.Items[i].Spec.Selector)
+		}
+	}
+	for i := range cronjobs.Items {
+		add("CronJob", &cronjobs.Items[i], cronjobs.Items[i].Spec.JobTemplate.Spec.Selector)
+	}
+	for i := range jobs.Items {
+		if !hasOwnerKind(jobs.Items[i].OwnerReferences, "CronJob") {
+			add("Job", &jobs.Items[i], jobs.Items[i].Spec.Selector)
 		}
-		workload.Pods = GetPodsForWorkload(replicaSets.Items, &statefulset, namespacedPods.Items, "StatefulSet")
-		n.Workloads = append(n.Workloads, workload)
+	}
+
+	unowned := &Workload{
+		Kind:      UnownedKind,
+		Name:      "Unowned pods",
+		Namespace: n.Name,
+	}
+	unowned.Pods = GetPodsForWorkload(owners, &metav1.ObjectMeta{}, namespacedPods.Items, UnownedKind)
+	if len(unowned.Pods) > 0 {
+		n.Workloads = append(n.Workloads, unowned)
 	}
 
 	return n
 }
+
+// labelSelector returns the selector as the labelSelector of a list
+// of pods, empty when it is nil or invalid
+func labelSelector(selector *metav1.LabelSelector) string {
+	if selector == nil {
+		return ""
+	}
+	s, err := metav1.LabelSelectorAsSelector(selector)
+	if err != nil {
+		return ""
+	}
+	return s.String()
+}
+
+func hasOwnerKind(owners []metav1.OwnerReference, kind string) bool {
+	for _, owner := range owners {
+		if owner.Kind == kind {
+			return true
+		}
+	}
+	return false
+}
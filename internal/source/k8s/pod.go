@@ -1,6 +1,7 @@
 package k8s
 
 import (
+	"fmt"
 	"strings"
 	"time"
 
@@ -15,6 +16,7 @@ type Pod struct {
 	Labels            map[string]string `json:"-"`
 	CreationTimestamp time.Time         `json:"creationTimestamp"`
 	Namespace         string            `json:"namespace"`
+	Node              string            `json:"node"`
 	Status            string            `json:"status"`
 	Containers        []*Container      `json:"containers"`
 }
@@ -24,9 +26,21 @@ func (n *Pod) Children() []source.ListItem {
 }
 
 func (n *Pod) String() string {
+	if restarts := n.RestartCount(); restarts > 0 {
+		return fmt.Sprintf("%s ↻%d", n.Name, restarts)
+	}
 	return n.Name
 }
 
+// RestartCount returns the number of restarts of all the containers of the pod
+func (n *Pod) RestartCount() int32 {
+	var restarts int32
+	for _, c := range n.Containers {
+		restarts += c.RestartCount
+	}
+	return restarts
+}
+
 func (n *Pod) FilterValue() string {
 	return n.Name
 }
@@ -39,6 +53,16 @@ func NewPod(pod v1.Pod) *Pod {
 			Image:    c.Image,
 			ImageTag: c.Image[strings.Index(c.Image, ":")+1:],
 		}
+		for _, cs := range pod.Status.ContainerStatuses {
+			if cs.Name != c.Name {
+				continue
+			}
+			containers[i].RestartCount = cs.RestartCount
+			if t := cs.LastTerminationState.Terminated; t != nil {
+				containers[i].LastTerminationReason = t.Reason
+				containers[i].LastExitCode = t.ExitCode
+			}
+		}
 	}
 
 	return &Pod{
@@ -47,6 +71,7 @@ func NewPod(pod v1.Pod) *Pod {
 		Labels:            pod.Labels,
 		CreationTimestamp: pod.CreationTimestamp.Time,
 		Namespace:         pod.Namespace,
+		Node:              pod.Spec.NodeName,
 		Status:            string(pod.Status.Phase),
 		Containers:        containers,
 	}
@@ -4,9 +4,10 @@ import (
 	"bufio"
 	"context"
 	"errors"
-	"path/filepath"
-	"strings"
+	"fmt"
+	"sort"
 	"sync"
+	"time"
 
 	"github.com/filipecaixeta/logviewer/internal/config"
 	"github.com/filipecaixeta/logviewer/internal/source"
@@ -14,51 +15,74 @@ import (
 
 	tea "github.com/charmbracelet/bubbletea"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
-	"k8s.io/client-go/util/homedir"
 )
 
 type Source struct {
 	columns   []*source.List
 	clientset *kubernetes.Clientset
-	cfg       *config.Config
-}
-
-func getClientset(k8sContext string) (*kubernetes.Clientset, error) {
-	var config *rest.Config
-	var err error
-
-	if home := homedir.HomeDir(); home != "" {
-		kubeconfig := filepath.Join(home, ".kube", "config")
-		// Use the provided K8sContext if it's not empty
-		if k8sContext != "" {
-			loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-			loadingRules.ExplicitPath = kubeconfig
-			configOverrides := &clientcmd.ConfigOverrides{CurrentContext: k8sContext}
-			kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-			config, err = kubeConfig.ClientConfig()
-		} else {
-			config, err = clientcmd.BuildConfigFromFlags("", kubeconfig)
-		}
-		if err != nil {
-			return nil, err
-		}
-	} else {
-		return nil, errors.New("Home directory not found, unable to locate kubeconfig file")
+	// streamClientset is used for log streams and watches, it has no request timeout
+	streamClientset *kubernetes.Clientset
+	cfg             *config.Config
+	// context is the name of the kubeconfig context loaded by Init
+	context string
+}
+
+// clientConfig loads the kubeconfig like kubectl does, from the explicit path
+// if set, otherwise from the files in $KUBECONFIG merged or ~/.kube/config.
+// The K8sContext is used if it's not empty, the current context otherwise.
+func clientConfig(cfg *config.Config) clientcmd.ClientConfig {
+	loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+	loadingRules.ExplicitPath = cfg.Kubeconfig
+	configOverrides := &clientcmd.ConfigOverrides{CurrentContext: cfg.K8sContext}
+	return clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+}
+
+// getClientset returns the clientset, the clientset used for log streams and
+// watches and the default namespace of the context. The streams don't have a
+// request timeout, as they are open for as long as the logs are displayed.
+// The in-cluster configuration is used when running inside a pod without
+// a kubeconfig.
+func getClientset(cfg *config.Config) (*kubernetes.Clientset, *kubernetes.Clientset, string, error) {
+	kubeConfig := clientConfig(cfg)
+
+	config, err := kubeConfig.ClientConfig()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	namespace, _, err := kubeConfig.Namespace()
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	// set here and not in the overrides because the in-cluster
+	// configuration ignores them
+	config.Impersonate = rest.ImpersonationConfig{
+		UserName: cfg.K8sAs,
+		Groups:   cfg.K8sAsGroups,
 	}
 
 	config.QPS = 40
 	config.Burst = 40
 	config.WarningHandler = rest.NoWarnings{}
 
+	streamClientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, nil, "", err
+	}
+
+	config.Timeout = cfg.K8sRequestTimeout
 	clientset, err := kubernetes.NewForConfig(config)
 	if err != nil {
-		return nil, err
+		return nil, nil, "", err
 	}
-	return clientset, nil
+	return clientset, streamClientset, namespace, nil
 }
 
 func New(config *config.Config) source.Source {
@@ -78,41 +102,79 @@ func (s *Source) Init(stateChan chan state.State) tea.Cmd {
 	return func() tea.Msg {
 		stateChan <- state.StateLoading
 
-		clientset, err := getClientset(s.cfg.K8sContext)
+		c, err := loadCluster(s.cfg)
 		if err != nil {
 			return err
 		}
+		s.setCluster(c)
 
-		s.clientset = clientset
-
-		namespaces := make([]*Namespace, len(s.cfg.Namespaces))
-		var wg sync.WaitGroup
-		wg.Add(len(s.cfg.Namespaces))
-		for i, namespace := range s.cfg.Namespaces {
-			go func(i int, namespace string) {
-				n := NewNamespace(namespace, clientset)
-				namespaces[i] = n
-				wg.Done()
-			}(i, namespace)
-		}
-		wg.Wait()
+		stateChan <- state.StateBrose
+		return nil
+	}
+}
 
-		s.columns[0].SetItems(source.ConvertInterface2ListItems(namespaces))
-		s.columns[0].ResetFilter()
-		s.columns[0].ResetSelected()
+// cluster holds what is loaded from the cluster of a context
+type cluster struct {
+	clientset       *kubernetes.Clientset
+	streamClientset *kubernetes.Clientset
+	context         string
+	namespaces      []*Namespace
+}
 
-		for i := 0; i < len(s.columns)-1; i++ {
-			if item := s.columns[i].SelectedItem(); item != nil {
-				s.columns[i+1].SetItems(item.Children())
-			} else {
-				s.columns[i+1].SetItems([]source.ListItem{})
-			}
-			s.columns[i+1].ResetFilter()
-			s.columns[i+1].ResetSelected()
+// loadCluster connects to the cluster of the K8sContext and loads its namespaces
+func loadCluster(cfg *config.Config) (*cluster, error) {
+	clientset, streamClientset, defaultNamespace, err := getClientset(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &cluster{
+		clientset:       clientset,
+		streamClientset: streamClientset,
+		context:         cfg.K8sContext,
+	}
+	if c.context == "" {
+		if raw, err := clientConfig(cfg).RawConfig(); err == nil {
+			c.context = raw.CurrentContext
 		}
+	}
 
-		stateChan <- state.StateBrose
-		return nil
+	names, err := ListNamespaces(clientset, cfg.Namespaces, defaultNamespace)
+	if err != nil {
+		return nil, err
+	}
+
+	c.namespaces = make([]*Namespace, len(names))
+	var wg sync.WaitGroup
+	wg.Add(len(names))
+	for i, namespace := range names {
+		go func(i int, namespace string) {
+			c.namespaces[i] = NewNamespace(namespace, clientset)
+			wg.Done()
+		}(i, namespace)
+	}
+	wg.Wait()
+	return c, nil
+}
+
+// setCluster replaces the clientsets and the columns with the loaded cluster
+func (s *Source) setCluster(c *cluster) {
+	s.clientset = c.clientset
+	s.streamClientset = c.streamClientset
+	s.context = c.context
+
+	s.columns[0].SetItems(source.ConvertInterface2ListItems(c.namespaces))
+	s.columns[0].ResetFilter()
+	s.columns[0].ResetSelected()
+
+	for i := 0; i < len(s.columns)-1; i++ {
+		if item := s.columns[i].SelectedItem(); item != nil {
+			s.columns[i+1].SetItems(item.Children())
+		} else {
+			s.columns[i+1].SetItems([]source.ListItem{})
+		}
+		s.columns[i+1].ResetFilter()
+		s.columns[i+1].ResetSelected()
 	}
 }
 
@@ -120,64 +182,320 @@ func (s *Source) Columns() []*source.List {
 	return s.columns
 }
 
-func (s *Source) getLogCfg() map[string]string {
-	cfg := map[string]string{}
+// HasPrevious reports that the logs of the crashed or restarted
+// containers can be streamed
+func (s *Source) HasPrevious() bool {
+	return true
+}
+
+// Contexts returns the contexts of the kubeconfig sorted by name
+func (s *Source) Contexts() ([]string, error) {
+	raw, err := clientConfig(s.cfg).RawConfig()
+	if err != nil {
+		return nil, err
+	}
+	contexts := make([]string, 0, len(raw.Contexts))
+	for name := range raw.Contexts {
+		contexts = append(contexts, name)
+	}
+	sort.Strings(contexts)
+	return contexts, nil
+}
+
+// Context returns the context loaded by Init, it is empty when
+// running with the in-cluster configuration
+func (s *Source) Context() string {
+	return s.context
+}
+
+// SetContext loads the cluster of the context, the active context is
+// kept until the returned ContextMsg is applied
+func (s *Source) SetContext(name string) tea.Cmd {
+	cfg := *s.cfg
+	cfg.K8sContext = name
+	return func() tea.Msg {
+		c, err := loadCluster(&cfg)
+		if err != nil {
+			return source.ContextMsg{Err: fmt.Errorf("context %s: %w", name, err)}
+		}
+		return source.ContextMsg{Update: func() {
+			s.cfg.K8sContext = name
+			s.setCluster(c)
+		}}
+	}
+}
+
+// logTarget is a container whose logs are streamed
+type logTarget struct {
+	namespace string
+	workload  string
+	pod       string
+	node      string
+	container string
+	image     string
+	// uid and restarts identify the instance of the container
+	uid      types.UID
+	restarts int32
+}
+
+func (t logTarget) meta() map[string]string {
+	meta := map[string]string{
+		"namespace": t.namespace,
+		"pod":       t.pod,
+		"container": t.container,
+		"image":     t.image,
+		"node":      t.node,
+	}
+	if t.workload != "" {
+		meta["workload"] = t.workload
+	}
+	return meta
+}
+
+// logSelection is what is selected in the columns up to the active one
+type logSelection struct {
+	namespace *Namespace
+	workload  *Workload
+	pod       *Pod
+	container *Container
+}
+
+func (s *Source) getLogSelection() logSelection {
+	var sel logSelection
 	for _, c := range s.columns {
-		s := c.SelectedItem()
-		cfg[strings.ToLower(c.Title)] = s.String()
+		switch item := c.SelectedItem().(type) {
+		case *Namespace:
+			sel.namespace = item
+		case *Workload:
+			sel.workload = item
+		case *Pod:
+			sel.pod = item
+		case *Container:
+			sel.container = item
+		}
 		if c.IsActive() {
 			break
 		}
 	}
-	return cfg
+	return sel
 }
 
-func (s *Source) Logs(ctx context.Context, stateChan chan state.State, logChan chan string) tea.Cmd {
-	cfg := s.getLogCfg()
-	return func() tea.Msg {
+// Meta returns the metadata of the selected namespace, workload,
+// pod and container
+func (s *Source) Meta() map[string]string {
+	sel := s.getLogSelection()
+	meta := map[string]string{}
+	if sel.namespace != nil {
+		meta["namespace"] = sel.namespace.Name
+	}
+	if sel.workload != nil {
+		meta["workload"] = sel.workload.Name
+	}
+	if sel.pod != nil {
+		meta["pod"] = sel.pod.Name
+		meta["node"] = sel.pod.Node
+	}
+	if sel.container != nil {
+		meta["container"] = sel.container.Name
+		meta["image"] = sel.container.Image
+	}
+	return meta
+}
 
-		if c, ok := cfg["containers"]; c == "" || !ok {
+func (s *Source) Logs(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return s.Target()(ctx, opts, stateChan, logChan)
+}
+
+// Target returns the stream of the namespace, workload, pod and container
+// selected now
+func (s *Source) Target() source.Stream {
+	sel := s.getLogSelection()
+	return func(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+		return s.logs(ctx, sel, opts, stateChan, logChan)
+	}
+}
+
+func (s *Source) logs(ctx context.Context, sel logSelection, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
+	return func() tea.Msg {
+		if sel.namespace == nil || (sel.workload == nil && sel.pod == nil) {
 			stateChan <- state.StateBrose
 			return nil
 		}
 
-		tail := int64(10000)
-		podLogOpts := v1.PodLogOptions{
-			Container: cfg["containers"],
-			TailLines: &tail,
-			Follow:    true,
+		if opts.Previous {
+			stateChan <- state.StateLogs
+			return s.previousLogs(ctx, sel, opts, logChan)
 		}
 
-		req := s.clientset.CoreV1().Pods(cfg["namespaces"]).GetLogs(cfg["pods"], &podLogOpts)
+		f := newPodFollower(s, sel, opts, logChan)
+		return f.run(ctx, func() { stateChan <- state.StateLogs })
+	}
+}
 
-		podLogs, err := req.Stream(ctx)
-		if err != nil {
-			return err
+// previousLogs sends the logs of the previous instance of the selected
+// containers, one container after the other. Containers that never
+// restarted are skipped.
+func (s *Source) previousLogs(ctx context.Context, sel logSelection, opts source.LogOptions, logChan chan source.LogLine) error {
+	pods := []*Pod{sel.pod}
+	if sel.pod == nil {
+		pods = sel.workload.Pods
+	}
+
+	var targets []logTarget
+	for _, pod := range pods {
+		for _, c := range pod.Containers {
+			if (sel.container != nil && c.Name != sel.container.Name) || c.RestartCount == 0 {
+				continue
+			}
+			t := logTarget{
+				namespace: sel.namespace.Name,
+				pod:       pod.Name,
+				node:      pod.Node,
+				container: c.Name,
+				image:     c.Image,
+			}
+			if sel.workload != nil {
+				t.workload = sel.workload.Name
+			}
+			targets = append(targets, t)
 		}
-		defer podLogs.Close()
+	}
 
-		scanner := bufio.NewScanner(podLogs)
-		const maxCapacity = 1024 * 1024 // 1MB, default was 64kb
-		buf := make([]byte, 0, maxCapacity)
-		scanner.Buffer(buf, maxCapacity)
-		stateChan <- state.StateLogs
+	if len(targets) == 0 {
+		s.marker(ctx, logChan, "no restarted container, there are no previous logs", nil)
+		return nil
+	}
 
-		if scanner.Scan() {
-			stateChan <- state.StateLogs
-			logChan <- scanner.Text()
+	podLogOpts := podLogOptions(opts, time.Now())
+	podLogOpts.Previous = true
+	podLogOpts.Follow = false
+	if opts.Tail > 0 {
+		tail := int64(opts.Tail)
+		podLogOpts.TailLines = &tail
+	}
+	for _, t := range targets {
+		s.marker(ctx, logChan, fmt.Sprintf("previous instance of container %s of pod %s", t.container, t.pod), t.meta())
+		if err := s.streamLogs(ctx, t, podLogOpts, opts.Until, nil, nil, logChan); err != nil && !errors.Is(err, source.ErrUntil) {
+			if ctx.Err() != nil {
+				return nil
+			}
+			s.marker(ctx, logChan, err.Error(), t.meta())
+		}
+	}
+	return nil
+}
+
+// marker sends a marker line to logChan
+func (s *Source) marker(ctx context.Context, logChan chan source.LogLine, text string, meta map[string]string) {
+	select {
+	case <-ctx.Done():
+	case logChan <- source.LogLine{Text: text, Meta: meta, Marker: true}:
+	}
+}
+
+// podLogOptions returns the options of the streams starting at now, without
+// the number of lines, which depends on the number of streamed containers.
+// The API can't stop at a time, so the timestamps are requested to stop the
+// streams at the first line after Until.
+func podLogOptions(opts source.LogOptions, now time.Time) v1.PodLogOptions {
+	podLogOpts := v1.PodLogOptions{
+		Follow:     opts.Follow(now),
+		Timestamps: !opts.Until.IsZero(),
+	}
+	if start := opts.Start(now); !start.IsZero() {
+		podLogOpts.SinceTime = &metav1.Time{Time: start}
+	}
+	return podLogOpts
+}
+
+// followLogs streams the logs of a running container until it stops,
+// reconnecting when the stream drops. The reconnected stream starts
+// from the time of the last line received.
+func (s *Source) followLogs(ctx context.Context, t logTarget, podLogOpts v1.PodLogOptions, until time.Time, logChan chan source.LogLine) error {
+	podLogOpts.Timestamps = true
+	resume := source.NewResume(time.Now())
+	connect := func(ctx context.Context, reconnect bool, connected func()) error {
+		opts := podLogOpts
+		if reconnect {
+			opts.TailLines = nil
+			opts.SinceTime = &metav1.Time{Time: resume.Since()}
 		}
+		return s.streamLogs(ctx, t, opts, until, resume, connected, logChan)
+	}
+	running := func(ctx context.Context) (bool, error) {
+		return s.running(ctx, t)
+	}
+	return source.Follow(ctx, logChan, t.meta(), connect, running)
+}
 
-		for scanner.Scan() {
-			t := scanner.Text()
-			logChan <- t
+// running reports whether the streamed instance of the container is still running
+func (s *Source) running(ctx context.Context, t logTarget) (bool, error) {
+	pod, err := s.clientset.CoreV1().Pods(t.namespace).Get(ctx, t.pod, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	if pod.UID != t.uid {
+		return false, nil
+	}
+	for _, cs := range pod.Status.ContainerStatuses {
+		if cs.Name == t.container {
+			return cs.State.Running != nil && cs.RestartCount == t.restarts, nil
 		}
+	}
+	return false, nil
+}
 
-		if err := scanner.Err(); err != nil {
-			return err
+// streamLogs sends the logs of a container to logChan until the stream ends.
+// When the timestamps are requested it returns ErrUntil at the first line
+// newer than until, and resume skips the lines already received, if not nil.
+// connected is called once the stream is open, if not nil.
+func (s *Source) streamLogs(ctx context.Context, t logTarget, podLogOpts v1.PodLogOptions, until time.Time, resume *source.Resume, connected func(), logChan chan source.LogLine) error {
+	podLogOpts.Container = t.container
+
+	req := s.streamClientset.CoreV1().Pods(t.namespace).GetLogs(t.pod, &podLogOpts)
+
+	podLogs, err := req.Stream(ctx)
+	if err != nil {
+		return err
+	}
+	defer podLogs.Close()
+	if connected != nil {
+		connected()
+	}
+
+	scanner := bufio.NewScanner(podLogs)
+	const maxCapacity = 1024 * 1024 // 1MB, default was 64kb
+	buf := make([]byte, 0, maxCapacity)
+	scanner.Buffer(buf, maxCapacity)
+
+	meta := t.meta()
+	for scanner.Scan() {
+		text := scanner.Text()
+		if podLogOpts.Timestamps {
+			var ts time.Time
+			ts, text = source.SplitTimestamp(text)
+			if !until.IsZero() && ts.After(until) {
+				return source.ErrUntil
+			}
+			if resume != nil && resume.Skip(ts) {
+				continue
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case logChan <- source.LogLine{Text: text, Meta: meta}:
 		}
+	}
 
-		return nil
+	if err := scanner.Err(); err != nil && ctx.Err() == nil {
+		return err
 	}
+
+	return nil
 }
 
 func (s *Source) Close() {
@@ -0,0 +1,382 @@
+package k8s
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/filipecaixeta/logviewer/internal/source"
+
+	batchv1 "k8s.io/api/batch/v1"
+	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// podFollower streams the logs of the selected workload, pod or container.
+// It watches the pods of the namespace, so it attaches the pods created
+// by a rollout as soon as they are running and the containers restarted
+// after a crash, and it adds a marker line when a pod starts or terminates.
+// Like the streams of Docker, it ends once the followed containers ended
+// and no pod started later can be followed.
+type podFollower struct {
+	src       *Source
+	namespace string
+	workload  *Workload
+	pod       string
+	container string
+	opts      source.LogOptions
+	logChan   chan source.LogLine
+	// podLogOpts are the options of every stream, computed when the follower starts
+	podLogOpts v1.PodLogOptions
+
+	// owners caches the ReplicaSets and Jobs that own pods
+	owners OwnerGraph
+	// restarts has the restart count of the streamed containers, by pod UID/container
+	restarts map[string]int32
+	// pods has the state of the pods with a stream by UID, true once terminated.
+	// StatefulSet pods are created again with the same name, so names can't be used.
+	pods map[types.UID]bool
+	wg   sync.WaitGroup
+
+	// podEnded is set when the selected pod terminated for good
+	podEnded bool
+	// streams is the number of streams being followed,
+	// streamEnded is signaled when one of them ends
+	streams     int
+	streamEnded chan struct{}
+}
+
+func newPodFollower(s *Source, sel logSelection, opts source.LogOptions, logChan chan source.LogLine) *podFollower {
+	f := &podFollower{
+		src:        s,
+		namespace:  sel.namespace.Name,
+		workload:   sel.workload,
+		opts:       opts,
+		logChan:    logChan,
+		podLogOpts: podLogOptions(opts, time.Now()),
+		owners:     OwnerGraph{},
+		restarts:   map[string]int32{},
+		pods:       map[types.UID]bool{},
+	}
+	f.streamEnded = make(chan struct{})
+	if sel.pod != nil {
+		f.pod = sel.pod.Name
+	}
+	if sel.container != nil {
+		f.container = sel.container.Name
+	}
+	return f
+}
+
+// run streams the logs until the context is cancelled.
+// started is called once the pods were listed.
+func (f *podFollower) run(ctx context.Context, started func()) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	err := f.follow(ctx, started)
+	if err != nil {
+		// the streams are stopped so the error isn't held until they end
+		cancel()
+	}
+	f.wg.Wait()
+	return err
+}
+
+// listOptions returns the options listing and watching the pods, only
+// the pods of the workload or the selected pod are sent by the API
+func (f *podFollower) listOptions() metav1.ListOptions {
+	var opts metav1.ListOptions
+	if f.pod != "" {
+		opts.FieldSelector = "metadata.name=" + f.pod
+	} else if f.workload != nil {
+		opts.LabelSelector = f.workload.Selector
+	}
+	return opts
+}
+
+func (f *podFollower) follow(ctx context.Context, started func()) error {
+	pods, err := f.src.clientset.CoreV1().Pods(f.namespace).List(ctx, f.listOptions())
+	if err != nil {
+		return err
+	}
+
+	// by default keep the number of lines loaded at start the same
+	// no matter how many containers are streamed
+	var owned []*v1.Pod
+	containers := 0
+	for i := range pods.Items {
+		if pod := &pods.Items[i]; f.owns(ctx, pod) {
+			owned = append(owned, pod)
+			containers += len(pod.Spec.Containers)
+		}
+	}
+	var tail *int64
+	switch {
+	case f.opts.Tail > 0:
+		tail = ptr(int64(f.opts.Tail))
+	case f.opts.Tail == 0:
+		tail = ptr(max(int64(10000/max(containers, 1)), 100))
+	}
+	for _, pod := range owned {
+		f.sync(ctx, pod, tail, true)
+	}
+	started()
+	if !f.podLogOpts.Follow {
+		return nil
+	}
+
+	resourceVersion := pods.ResourceVersion
+	attempt := 0
+	for ctx.Err() == nil {
+		if f.finished(ctx) {
+			return nil
+		}
+		opts := f.listOptions()
+		opts.ResourceVersion = resourceVersion
+		w, err := f.src.streamClientset.CoreV1().Pods(f.namespace).Watch(ctx, opts)
+		if apierrors.IsForbidden(err) || apierrors.IsUnauthorized(err) {
+			return fmt.Errorf("can't watch the pods of %s: %w", f.namespace, err)
+		}
+		if err != nil {
+			// the API may be down for a while, keep the streams
+			// and watch again from the current state
+			resourceVersion = ""
+			select {
+			case <-ctx.Done():
+			case <-f.streamEnded:
+				f.streams--
+			case <-time.After(source.Backoff(attempt)):
+			}
+			attempt++
+			continue
+		}
+		attempt = 0
+	events:
+		for {
+			select {
+			case <-ctx.Done():
+				break events
+			case <-f.streamEnded:
+				f.streams--
+			case event, ok := <-w.ResultChan():
+				if !ok {
+					break events
+				}
+				pod, ok := event.Object.(*v1.Pod)
+				if !ok {
+					// the resource version expired, watch again from the current state,
+					// the pods already streamed are not attached twice
+					resourceVersion = ""
+					break events
+				}
+				resourceVersion = pod.ResourceVersion
+				switch event.Type {
+				case watch.Added, watch.Modified:
+					if f.owns(ctx, pod) {
+						f.sync(ctx, pod, nil, false)
+					}
+				case watch.Deleted:
+					f.terminated(ctx, pod)
+				}
+			}
+			if f.finished(ctx) {
+				w.Stop()
+				return nil
+			}
+		}
+		w.Stop()
+	}
+	return nil
+}
+
+// finished reports whether the logs ended: no container is streamed and
+// no pod started later can be followed. That is when the selected pod
+// terminated, the Job completed or the workload was deleted
+func (f *podFollower) finished(ctx context.Context) bool {
+	if f.streams > 0 {
+		return false
+	}
+	if f.pod != "" {
+		return f.podEnded
+	}
+	if f.workload == nil {
+		return false
+	}
+
+	var err error
+	name := f.workload.Name
+	switch f.workload.Kind {
+	case "Job":
+		var job *batchv1.Job
+		job, err = f.src.clientset.BatchV1().Jobs(f.namespace).Get(ctx, name, metav1.GetOptions{})
+		if err == nil {
+			return jobFinished(job)
+		}
+	case "CronJob":
+		_, err = f.src.clientset.BatchV1().CronJobs(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "Deployment":
+		_, err = f.src.clientset.AppsV1().Deployments(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "StatefulSet":
+		_, err = f.src.clientset.AppsV1().StatefulSets(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "DaemonSet":
+		_, err = f.src.clientset.AppsV1().DaemonSets(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	case "ReplicaSet":
+		_, err = f.src.clientset.AppsV1().ReplicaSets(f.namespace).Get(ctx, name, metav1.GetOptions{})
+	default:
+		// any pod may be created without an owner
+		return false
+	}
+	return apierrors.IsNotFound(err)
+}
+
+// jobFinished reports whether the Job completed or failed,
+// it creates no more pods then
+func jobFinished(job *batchv1.Job) bool {
+	for _, c := range job.Status.Conditions {
+		if (c.Type == batchv1.JobComplete || c.Type == batchv1.JobFailed) && c.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
+
+// owns reports whether the pod is one of the followed pods
+func (f *podFollower) owns(ctx context.Context, pod *v1.Pod) bool {
+	if f.pod != "" {
+		return pod.Name == f.pod
+	}
+
+	// a rollout creates a new ReplicaSet and a CronJob a new Job,
+	// fetch the ones that are not known yet
+	for _, owner := range pod.OwnerReferences {
+		if _, ok := f.owners[owner.UID]; ok {
+			continue
+		}
+		switch owner.Kind {
+		case "ReplicaSet":
+			if rs, err := f.src.clientset.AppsV1().ReplicaSets(f.namespace).Get(ctx, owner.Name, metav1.GetOptions{}); err == nil {
+				f.owners.Add(rs)
+			}
+		case "Job":
+			if job, err := f.src.clientset.BatchV1().Jobs(f.namespace).Get(ctx, owner.Name, metav1.GetOptions{}); err == nil {
+				f.owners.Add(job)
+			}
+		}
+	}
+
+	return IsPodOwnedBy(f.owners, f.workload.UID, pod, f.workload.Kind)
+}
+
+// sync starts a stream for every running container of the pod that is not
+// streamed yet. initial is true for the pods that were running before the
+// follower started, tail limits their number of lines. It is nil for new
+// pods and restarted containers as all their lines are new.
+func (f *podFollower) sync(ctx context.Context, pod *v1.Pod, tail *int64, initial bool) {
+	if pod.DeletionTimestamp != nil || pod.Status.Phase == v1.PodSucceeded || pod.Status.Phase == v1.PodFailed {
+		f.terminated(ctx, pod)
+		return
+	}
+
+	_, known := f.pods[pod.UID]
+	for _, cs := range pod.Status.ContainerStatuses {
+		if f.container != "" && cs.Name != f.container {
+			continue
+		}
+		if cs.State.Running == nil {
+			continue
+		}
+
+		key := string(pod.UID) + "/" + cs.Name
+		restarts, streamed := f.restarts[key]
+		if streamed && restarts == cs.RestartCount {
+			continue
+		}
+		f.restarts[key] = cs.RestartCount
+
+		switch {
+		case streamed:
+			f.marker(ctx, pod, fmt.Sprintf("container %s of pod %s restarted", cs.Name, pod.Name))
+		case !known && !initial:
+			f.marker(ctx, pod, fmt.Sprintf("pod %s started", pod.Name))
+		}
+		known = true
+		f.pods[pod.UID] = false
+
+		podLogOpts := f.podLogOpts
+		podLogOpts.TailLines = tail
+		t := f.target(pod, cs)
+		f.wg.Add(1)
+		if podLogOpts.Follow {
+			f.streams++
+		}
+		go func() {
+			defer f.wg.Done()
+			if !podLogOpts.Follow {
+				_ = f.src.streamLogs(ctx, t, podLogOpts, f.opts.Until, nil, nil, f.logChan)
+				return
+			}
+			_ = f.src.followLogs(ctx, t, podLogOpts, f.opts.Until, f.logChan)
+			select {
+			case f.streamEnded <- struct{}{}:
+			case <-ctx.Done():
+			}
+		}()
+	}
+}
+
+// terminated adds a marker for a streamed pod that terminated
+func (f *podFollower) terminated(ctx context.Context, pod *v1.Pod) {
+	// a StatefulSet creates its pods again with the same name
+	if pod.Name == f.pod && !isControlledBy(pod, "StatefulSet") {
+		f.podEnded = true
+	}
+	if done, ok := f.pods[pod.UID]; !ok || done {
+		return
+	}
+	f.pods[pod.UID] = true
+	f.marker(ctx, pod, fmt.Sprintf("pod %s terminated", pod.Name))
+}
+
+func (f *podFollower) marker(ctx context.Context, pod *v1.Pod, text string) {
+	meta := map[string]string{
+		"namespace": f.namespace,
+		"pod":       pod.Name,
+	}
+	if f.workload != nil {
+		meta["workload"] = f.workload.Name
+	}
+	f.src.marker(ctx, f.logChan, text, meta)
+}
+
+func isControlledBy(pod *v1.Pod, kind string) bool {
+	owner := metav1.GetControllerOf(pod)
+	return owner != nil && owner.Kind == kind
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func (f *podFollower) target(pod *v1.Pod, cs v1.ContainerStatus) logTarget {
+	t := logTarget{
+		namespace: f.namespace,
+		pod:       pod.Name,
+		node:      pod.Spec.NodeName,
+		container: cs.Name,
+		uid:       pod.UID,
+		restarts:  cs.RestartCount,
+	}
+	if f.workload != nil {
+		t.workload = f.workload.Name
+	}
+	for _, c := range pod.Spec.Containers {
+		if c.Name == cs.Name {
+			t.image = c.Image
+		}
+	}
+	return t
+}
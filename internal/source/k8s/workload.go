@@ -6,8 +6,10 @@ import (
 	"github.com/filipecaixeta/logviewer/internal/source"
 
 	appsv1 "k8s.io/api/apps/v1"
+	batchv1 "k8s.io/api/batch/v1"
 	v1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 )
 
 type Workload struct {
@@ -18,6 +20,8 @@ type Workload struct {
 	CreationTimestamp time.Time         `json:"creationTimestamp"`
 	Namespace         string            `json:"namespace"`
 	Pods              []*Pod            `json:"pods"`
+	// Selector is the label selector of its pods, empty when it has none
+	Selector string `json:"-"`
 }
 
 func (n *Workload) Children() []source.ListItem {
@@ -32,57 +36,86 @@ func (n *Workload) FilterValue() string {
 	return n.Name
 }
 
+// UnownedKind is the kind of the pseudo-workload that groups the pods
+// without an owner, like debug pods created with kubectl run
+const UnownedKind = "Unowned"
+
+// maxOwnerDepth is the number of owners between a pod and its workload,
+// a CronJob owns Jobs that own Pods
+const maxOwnerDepth = 2
+
+// OwnerGraph maps the UID of the objects between a workload and its pods,
+// the ReplicaSets of a Deployment and the Jobs of a CronJob, to their owners
+type OwnerGraph map[types.UID][]metav1.OwnerReference
+
+// NewOwnerGraph creates an OwnerGraph from the ReplicaSets and Jobs in the namespace
+func NewOwnerGraph(replicaSets []appsv1.ReplicaSet, jobs []batchv1.Job) OwnerGraph {
+	g := OwnerGraph{}
+	for i := range replicaSets {
+		g.Add(&replicaSets[i])
+	}
+	for i := range jobs {
+		g.Add(&jobs[i])
+	}
+	return g
+}
+
+// Add adds the owners of an object to the graph
+func (g OwnerGraph) Add(obj metav1.Object) {
+	g[obj.GetUID()] = obj.GetOwnerReferences()
+}
+
+// owns reports whether uid is one of the owners or is an owner of an
+// owner up to maxOwnerDepth levels
+func (g OwnerGraph) owns(uid types.UID, owners []metav1.OwnerReference, depth int) bool {
+	for _, owner := range owners {
+		if owner.UID == uid {
+			return true
+		}
+		if parents, ok := g[owner.UID]; ok && depth < maxOwnerDepth && g.owns(uid, parents, depth+1) {
+			return true
+		}
+	}
+	return false
+}
+
 // GetPodsForWorkload finds and returns a list of pods associated with a given workload.
-// The workload can be either a Deployment or a StatefulSet.
 //
 // Parameters:
-// - replicaSets: A slice of ReplicaSet objects in the namespace.
-// - workload: The specific workload object (Deployment or StatefulSet).
+// - owners: The ReplicaSets and Jobs in the namespace.
+// - workload: The specific workload object (Deployment, StatefulSet, DaemonSet, ReplicaSet, Job or CronJob).
 // - namespacedPods: A slice of Pod objects in the namespace.
-// - workloadKind: A string indicating the type of the workload ("Deployment" or "StatefulSet").
+// - workloadKind: A string indicating the type of the workload ("Deployment", "StatefulSet", ...)
+// or UnownedKind for the pods without an owner.
 //
 // Returns:
 // - A slice of Pod objects that are associated with the given workload.
-func GetPodsForWorkload(replicaSets []appsv1.ReplicaSet, workload metav1.Object, namespacedPods []v1.Pod, workloadKind string) []*Pod {
+func GetPodsForWorkload(owners OwnerGraph, workload metav1.Object, namespacedPods []v1.Pod, workloadKind string) []*Pod {
 	var podsForWorkload []*Pod
 	workloadUID := string(workload.GetUID())
 
 	switch workloadKind {
-	case "Deployment":
-		// Find all ReplicaSets owned by the Deployment.
-		var ownedReplicaSets []appsv1.ReplicaSet
-		for _, rs := range replicaSets {
-			for _, owner := range rs.OwnerReferences {
-				if string(owner.UID) == workloadUID {
-					ownedReplicaSets = append(ownedReplicaSets, rs)
-				}
-			}
-		}
-
-		// Find all Pods owned by these ReplicaSets.
-		for _, rs := range ownedReplicaSets {
-			rsUID := string(rs.UID)
-			for _, pod := range namespacedPods {
-				for _, owner := range pod.OwnerReferences {
-					if string(owner.UID) == rsUID {
-						podsForWorkload = append(podsForWorkload, NewPod(pod))
-					}
-				}
-			}
-		}
-	case "StatefulSet":
-		// Find all Pods owned by the StatefulSet.
-		for _, pod := range namespacedPods {
-			for _, owner := range pod.OwnerReferences {
-				if string(owner.UID) == workloadUID {
-					podsForWorkload = append(podsForWorkload, NewPod(pod))
-				}
-			}
-		}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job", "CronJob", UnownedKind:
 	default:
 		// if the workload kind is not supported.
 		return nil
 	}
 
+	for _, pod := range namespacedPods {
+		if IsPodOwnedBy(owners, workloadUID, &pod, workloadKind) {
+			podsForWorkload = append(podsForWorkload, NewPod(pod))
+		}
+	}
+
 	return podsForWorkload
 }
+
+// IsPodOwnedBy reports whether the pod belongs to the workload with the given UID.
+// Deployments own their pods through ReplicaSets and CronJobs through Jobs,
+// so owners must contain the ReplicaSets and Jobs that own the pod.
+func IsPodOwnedBy(owners OwnerGraph, workloadUID string, pod *v1.Pod, workloadKind string) bool {
+	if workloadKind == UnownedKind {
+		return len(pod.OwnerReferences) == 0
+	}
+	return owners.owns(types.UID(workloadUID), pod.OwnerReferences, 0)
+}
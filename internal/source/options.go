@@ -0,0 +1,105 @@
+package source
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Start returns the time of the oldest log of a stream starting at now,
+// it is zero when the stream is not limited
+func (o LogOptions) Start(now time.Time) time.Time {
+	if o.Since > 0 {
+		return now.Add(-o.Since)
+	}
+	return o.SinceTime
+}
+
+// Follow reports whether a stream starting at now has to wait for new logs
+func (o LogOptions) Follow(now time.Time) bool {
+	return o.Until.IsZero() || o.Until.After(now)
+}
+
+// String returns the time window of the options in the format read by
+// ParseLogWindow, like "since=15m tail=500"
+func (o LogOptions) String() string {
+	var parts []string
+	switch {
+	case o.Since > 0:
+		parts = append(parts, "since="+o.Since.String())
+	case !o.SinceTime.IsZero():
+		parts = append(parts, "since="+o.SinceTime.Format(time.RFC3339))
+	}
+	if !o.Until.IsZero() {
+		parts = append(parts, "until="+o.Until.Format(time.RFC3339))
+	}
+	switch {
+	case o.Tail > 0:
+		parts = append(parts, "tail="+strconv.Itoa(o.Tail))
+	case o.Tail < 0:
+		parts = append(parts, "tail=all")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ParseLogWindow parses a time window like "since=15m until=2024-05-01T10:00:00Z tail=500"
+// into a copy of opts. since and until take a duration or a RFC3339 time, a duration
+// of until is counted back from now. tail takes a number of lines or "all".
+// The keys that are not in the window are reset.
+func ParseLogWindow(window string, opts LogOptions, now time.Time) (LogOptions, error) {
+	opts.Since = 0
+	opts.SinceTime = time.Time{}
+	opts.Until = time.Time{}
+	opts.Tail = 0
+
+	for _, field := range strings.Fields(window) {
+		k, v, ok := strings.Cut(field, "=")
+		if !ok || v == "" {
+			return opts, fmt.Errorf("%q is not key=value", field)
+		}
+		switch k {
+		case "since":
+			if d, err := time.ParseDuration(v); err == nil {
+				opts.Since = d
+				continue
+			}
+			t, err := ParseTime(v, now)
+			if err != nil {
+				return opts, err
+			}
+			opts.SinceTime = t
+		case "until":
+			t, err := ParseTime(v, now)
+			if err != nil {
+				return opts, err
+			}
+			opts.Until = t
+		case "tail":
+			if v == "all" {
+				opts.Tail = -1
+				continue
+			}
+			n, err := strconv.Atoi(v)
+			if err != nil || n <= 0 {
+				return opts, fmt.Errorf("tail must be a positive number of lines or all, got %q", v)
+			}
+			opts.Tail = n
+		default:
+			return opts, fmt.Errorf("unknown key %q, use since, until or tail", k)
+		}
+	}
+	return opts, nil
+}
+
+// ParseTime parses a RFC3339 time, or a duration counted back from now
+func ParseTime(value string, now time.Time) (time.Time, error) {
+	if d, err := time.ParseDuration(value); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is neither a duration like 15m nor a time like 2024-05-01T10:00:00Z", value)
+	}
+	return t, nil
+}
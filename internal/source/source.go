@@ -2,14 +2,112 @@ package source
 
 import (
 	"context"
+	"time"
 
 	"github.com/filipecaixeta/logviewer/internal/state"
 
 	tea "github.com/charmbracelet/bubbletea"
 )
 
+// LogLine is a line of log sent by a source to the logs view.
+// Meta identifies where the line came from, using the keys namespace,
+// workload, pod, container, image, node and stream for containers,
+// project for Docker Compose and file for log files. Sources only set
+// the keys they know about.
+//
+// Marker lines are not logs but events of the stream, like a pod that
+// started, they are always displayed. Lines with a Status tell the logs
+// view the state of a stream changed, they are only displayed when they
+// have a text.
+type LogLine struct {
+	Text   string
+	Meta   map[string]string
+	Marker bool
+	Status StreamStatus
+}
+
+// LogOptions are the options of a log stream.
+// Sources ignore the options they don't support.
+type LogOptions struct {
+	// Previous streams the logs of the previous instance of a container,
+	// the one that crashed or was restarted
+	Previous bool
+	// Since only streams the logs newer than a duration, counted from
+	// when the stream starts. SinceTime is used when Since is not set.
+	Since     time.Duration
+	SinceTime time.Time
+	// Until stops the stream at the first log newer than a time
+	Until time.Time
+	// Tail is the number of lines loaded at start, 0 loads the default
+	// of the source and a negative number loads all of them
+	Tail int
+}
+
 type Source interface {
 	Init(stateChan chan state.State) tea.Cmd
 	Columns() []*List
-	Logs(ctx context.Context, stateChan chan state.State, logChan chan string) tea.Cmd
+	Logs(ctx context.Context, opts LogOptions, stateChan chan state.State, logChan chan LogLine) tea.Cmd
+}
+
+// Stream streams the logs of a target, like Source.Logs
+type Stream func(ctx context.Context, opts LogOptions, stateChan chan state.State, logChan chan LogLine) tea.Cmd
+
+// TargetSource is implemented by the sources that stream the items selected
+// in the columns. Target captures the selection, the stream it returns keeps
+// streaming the same items when the selection changes, like on a reload.
+type TargetSource interface {
+	Target() Stream
+}
+
+// ContextSource is implemented by the sources that can switch between
+// clusters, like the contexts of a kubeconfig.
+type ContextSource interface {
+	// Contexts returns the names of the available contexts
+	Contexts() ([]string, error)
+	// Context returns the name of the active context
+	Context() string
+	// SetContext loads the context, the command returns a ContextMsg
+	SetContext(name string) tea.Cmd
+}
+
+// ErrorMsg is an error of a source shown in the browse view, unlike
+// the other errors it doesn't stop the app
+type ErrorMsg struct {
+	Err error
+}
+
+// ContextMsg is returned when a context is loaded. Update switches to
+// the new context and must be called from the UI, it is nil when the
+// context couldn't be loaded and Err is set, the old context is kept.
+type ContextMsg struct {
+	Update func()
+	Err    error
+}
+
+// PreviousSource is implemented by the sources that stream the logs of
+// the previous instance of a container when LogOptions.Previous is set
+type PreviousSource interface {
+	HasPrevious() bool
+}
+
+// AllSource is implemented by the sources that hide some items unless
+// asked to show them all, like the stopped Docker containers
+type AllSource interface {
+	ShowAll() bool
+	SetShowAll(all bool)
+}
+
+// MetaSource is implemented by the sources that know the metadata of the
+// selected items before their logs are streamed, it has the keys of
+// LogLine.Meta and is matched against the selectors of the views
+type MetaSource interface {
+	Meta() map[string]string
+}
+
+// ColumnsMsg is sent by the sources that watch their items for changes.
+// Update changes the columns and must be called from the UI, Next waits
+// for the following change.
+type ColumnsMsg struct {
+	Update func()
+	Next   tea.Cmd
 }
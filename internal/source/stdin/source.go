@@ -58,7 +58,7 @@ func (f *Stdin) Columns() []*source.List {
 	return f.columns
 }
 
-func (f *Stdin) Logs(ctx context.Context, stateChan chan state.State, logChan chan string) tea.Cmd {
+func (f *Stdin) Logs(ctx context.Context, opts source.LogOptions, stateChan chan state.State, logChan chan source.LogLine) tea.Cmd {
 	return func() tea.Msg {
 		stateChan <- state.StateLogs
 
@@ -66,7 +66,7 @@ func (f *Stdin) Logs(ctx context.Context, stateChan chan state.State, logChan ch
 			select {
 			case <-ctx.Done():
 				return nil
-			case logChan <- f.scanner.Text():
+			case logChan <- source.LogLine{Text: f.scanner.Text()}:
 			}
 		}
 		return nil
@@ -7,6 +7,5 @@ var (
 	StateBrose       = State(1)
 	StateLogsLoading = State(2)
 	StateLogs        = State(3)
-	StateNewView     = State(3)
 	StateLoadView    = State(4)
 )